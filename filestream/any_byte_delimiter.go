@@ -0,0 +1,38 @@
+package filestream
+
+// DelimiteByAnyByte, builds a DataChunkDelimiter that splits the data at any of the given bytes, such as " \t" for
+// whitespace separated tokens or a single "," for a plain list, the byte found is dropped. When collapse is set a run
+// of consecutive delimiter bytes is a single boundary, so no empty chunk is ever emitted, not even for delimiter bytes
+// at the beginning or at the end of the data source.
+// NOTE: when collapse is set, a run of delimiter bytes at the beginning of the data is dropped as soon as it is found,
+// so the data handed back while more data is needed may be shorter than the one received.
+func DelimiteByAnyByte(delimiters []byte, collapse bool) DataChunkDelimiter {
+	var isDelimiter [256]bool
+
+	for _, c := range delimiters {
+		isDelimiter[c] = true
+	}
+
+	return func(chunk []byte) (bool, []byte, []byte) {
+		start := 0
+
+		if collapse {
+			for start < len(chunk) && isDelimiter[chunk[start]] {
+				start++
+			}
+		}
+
+		for end := start; end < len(chunk); end++ {
+			if !isDelimiter[chunk[end]] {
+				continue
+			}
+
+			leftOver := make([]byte, len(chunk)-end-1)
+			copy(leftOver, chunk[end+1:])
+
+			return true, chunk[start:end], leftOver
+		}
+
+		return false, chunk[start:], nil
+	}
+}
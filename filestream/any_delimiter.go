@@ -0,0 +1,36 @@
+package filestream
+
+// DelimiteByAny, builds a DataChunkDelimiter for data sources interleaving records of different formats, such as NDJSON
+// lines and "---" separated YAML blocks, that gives the data to every delimiter and keeps the chunk of the one that
+// consumed the fewest bytes, chunk and terminator included, which is the one whose separator appears first, so the
+// left over is always the one of the winning delimiter. Ties go to the delimiter given first. Whenever no delimiter
+// finds a chunk the data is kept as it is until the next read.
+// NOTE: the data consumed by a delimiter is told by the left over it returns, so it suits delimiters that hand back the
+// left over as they found it, and since every delimiter is given the same data again and again, none of them may hold
+// any state about the data, which rules out delimiters such as the one built by DelimiteByJSONAuto.
+func DelimiteByAny(chunkDelimiters ...DataChunkDelimiter) DataChunkDelimiter {
+	return func(chunk []byte) (bool, []byte, []byte) {
+		found := false
+		var bestChunk, bestLeftOver []byte
+
+		for _, chunkDelimiter := range chunkDelimiters {
+			enough, delimited, leftOver := chunkDelimiter(chunk)
+
+			if !enough {
+				continue
+			}
+
+			if !found || len(leftOver) > len(bestLeftOver) {
+				found = true
+				bestChunk = delimited
+				bestLeftOver = leftOver
+			}
+		}
+
+		if !found {
+			return false, chunk, nil
+		}
+
+		return true, bestChunk, bestLeftOver
+	}
+}
@@ -0,0 +1,41 @@
+package filestream
+
+// BatchHandler, builds a DataChunkHandler that collects the chunks in batches of n, flushing every batch as soon as it
+// is full, such as for a batched INSERT when bulk loading a database, along with the function that flushes the last,
+// partial, batch, which must be called once the processing is over, such as by WithOnComplete. An error returned by the
+// flush function stops the processing, just as one from any handler.
+// NOTE: the chunks are kept until their batch is flushed, so they are copied, and the batch is never reused, so the
+// flush function is free to retain it.
+func BatchHandler(n int, flush func(batch [][]byte) error) (DataChunkHandler, func() error) {
+	if n < 1 {
+		n = 1
+	}
+
+	batch := make([][]byte, 0, n)
+
+	chunkHandler := func(b []byte) error {
+		batch = append(batch, append([]byte(nil), b...))
+
+		if len(batch) < n {
+			return nil
+		}
+
+		full := batch
+		batch = make([][]byte, 0, n)
+
+		return flush(full)
+	}
+
+	flushRest := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+
+		rest := batch
+		batch = make([][]byte, 0, n)
+
+		return flush(rest)
+	}
+
+	return chunkHandler, flushRest
+}
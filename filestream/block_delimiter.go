@@ -0,0 +1,37 @@
+package filestream
+
+import "bytes"
+
+const nulByte = byte(0)
+
+// DelimiteByPaddedBlock, builds a DataChunkDelimiter for formats made of fixed size blocks padded with NUL bytes, like
+// the 512 bytes records of tar, each block is emitted with its trailing NUL padding stripped. A block made only of NUL
+// bytes marks the end of the content, it is dropped along with everything read after it, so no other block is emitted.
+// NOTE: the data source is still read until it ends, but the bytes after the end block are dropped as they arrive
+// instead of being collected.
+func DelimiteByPaddedBlock(blockSize int) DataChunkDelimiter {
+	ended := false
+
+	return func(chunk []byte) (bool, []byte, []byte) {
+		// more data is never going to be a block once the end block was found, so it is dropped as it arrives.
+		if ended {
+			return false, chunk[len(chunk):], nil
+		}
+
+		if len(chunk) < blockSize {
+			return false, chunk, nil
+		}
+
+		block := bytes.TrimRight(chunk[:blockSize], string(nulByte))
+
+		if len(block) == 0 {
+			ended = true
+			return false, chunk[len(chunk):], nil
+		}
+
+		leftOver := make([]byte, len(chunk)-blockSize)
+		copy(leftOver, chunk[blockSize:])
+
+		return true, block, leftOver
+	}
+}
@@ -0,0 +1,49 @@
+package filestream
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDelimiteByPaddedBlock(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []string
+	}{
+		{"padded blocks", "ab\x00\x00cd\x00\x00", []string{"ab", "cd"}},
+		{"full block", "abcd", []string{"abcd"}},
+		{"end block", "ab\x00\x00\x00\x00\x00\x00", []string{"ab"}},
+		{"data after the end block", "ab\x00\x00\x00\x00\x00\x00cd\x00\x00zzzzzz", []string{"ab"}},
+		{"short last block", "ab\x00\x00c", []string{"ab", "c"}},
+		{"empty", "", []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := collectChunks(t, tt.data, withDelimiter(func() DataChunkDelimiter {
+				return DelimiteByPaddedBlock(4)
+			}))
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDelimiteByPaddedBlockDropsDataAfterTheEndBlock(t *testing.T) {
+	chunkDelimiter := DelimiteByPaddedBlock(4)
+
+	if _, chunk, _ := chunkDelimiter([]byte("\x00\x00\x00\x00tail")); len(chunk) != 0 {
+		t.Fatalf("the end block was kept: %q", chunk)
+	}
+
+	if _, chunk, _ := chunkDelimiter([]byte("more data")); len(chunk) != 0 {
+		t.Fatalf("data after the end block was kept: %q", chunk)
+	}
+}
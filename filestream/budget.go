@@ -0,0 +1,55 @@
+package filestream
+
+import (
+	"context"
+	"time"
+)
+
+// HandlerBudget, how much a handler is meant to spend on a single chunk, a hint handed to it through its context so
+// handlers, and the libraries they call, can throttle themselves, nothing enforces it.
+type HandlerBudget struct {
+	// Bytes, how much memory the handler should allocate at most for a chunk, zero means there is no limit.
+	Bytes int64
+	// Time, how long the handler should take at most for a chunk, zero means there is no limit.
+	Time time.Duration
+}
+
+// handlerBudgetKey, the key the HandlerBudget is kept under in a context.
+type handlerBudgetKey struct{}
+
+// ContextWithHandlerBudget, returns a copy of the context carrying the given HandlerBudget.
+func ContextWithHandlerBudget(ctx context.Context, budget HandlerBudget) context.Context {
+	return context.WithValue(ctx, handlerBudgetKey{}, budget)
+}
+
+// HandlerBudgetFromContext, returns the HandlerBudget carried by the context, along with whether it carries any.
+func HandlerBudgetFromContext(ctx context.Context) (HandlerBudget, bool) {
+	budget, found := ctx.Value(handlerBudgetKey{}).(HandlerBudget)
+
+	return budget, found
+}
+
+// DataChunkHandlerWithContext, same as DataChunkHandler but it also receives the context of the processing, carrying
+// the HandlerBudget set by WithHandlerBudget, if any.
+type DataChunkHandlerWithContext func(ctx context.Context, chunk []byte) error
+
+// WithHandlerBudget, sets the HandlerBudget carried by the context handed to the handlers run by RunContext.
+func WithHandlerBudget(budget HandlerBudget) Option {
+	return func(p *Processor) {
+		p.budget = &budget
+	}
+}
+
+// RunContext, same as Run but the handler also receives the context set by WithContext, carrying the HandlerBudget
+// set by WithHandlerBudget, if any.
+func (p *Processor) RunContext(chunkHandler DataChunkHandlerWithContext) error {
+	ctx := p.ctx
+
+	if p.budget != nil {
+		ctx = ContextWithHandlerBudget(ctx, *p.budget)
+	}
+
+	return p.Run(func(b []byte) error {
+		return chunkHandler(ctx, b)
+	})
+}
@@ -0,0 +1,546 @@
+package filestream
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// readBufferPool, the buffers the data source is read into, they are only used until the bytes read are appended to
+// the data being delimited, so they are shared by all the scanners instead of being allocated for every read.
+var readBufferPool = sync.Pool{
+	New: func() interface{} {
+		b := make([]byte, 0)
+		return &b
+	},
+}
+
+// ChunkScanner, pulls the chunks of a data source one at a time, in a loop, modeled on bufio.Scanner, instead of having
+// them pushed to a DataChunkHandler, the chunks found are exactly the same ProcessDataSourceInChunks hands over, since
+// it is built on top of it.
+//
+//	scanner := filestream.NewChunkScanner(dataSource, 128, filestream.DelimiteByNewLine)
+//
+//	for scanner.Scan() {
+//		process(scanner.Bytes())
+//	}
+//
+//	if err := scanner.Err(); err != nil {
+//		...
+//	}
+type ChunkScanner struct {
+	ctx            context.Context
+	dataSource     io.Reader
+	chunkSize      int
+	maxChunkSize   int
+	chunkDelimiter scannerDelimiter
+
+	// delimitsAtEOF, whether the delimiter is a DataChunkDelimiterWithEOF, which decides what the data left at the end
+	// of the data source is instead of having it handed over as the last chunk.
+	delimitsAtEOF bool
+
+	leftOver []byte
+	chunk    []byte
+	err      error
+	done     bool
+
+	// lines, whenever the delimiter is DelimiteByNewLine or DelimiteByLine, the reader the lines are scanned by instead,
+	// and trimCarriageReturn, whether the lines end with an optional carriage return as well.
+	lines              *bufio.Reader
+	trimCarriageReturn bool
+
+	// buffer, the memory the data being delimited is collected in, it is reused for every chunk, which is why a chunk
+	// is only valid until the next one is looked for.
+	buffer []byte
+
+	// sourceEOF, whether the data source already returned an EOF, and eof, whether the last chunk was already found.
+	sourceEOF bool
+	eof       bool
+
+	// bytesRead, all the bytes read from the data source so far, consumed, the ones among them that were already
+	// delimited into chunks, delimiter bytes included, and chunkOffset, where the bytes of the current chunk start.
+	bytesRead   int64
+	consumed    int64
+	chunkOffset int64
+
+	// chunkIndex, the position of the current chunk among all the chunks found, starting at one.
+	chunkIndex int
+
+	// strictTermination, whether data left after the last delimited chunk is an error instead of the last chunk.
+	strictTermination bool
+}
+
+// scannerDelimiter, the form every delimiter is adapted to by the scanner, a DataChunkDelimiterWithLookahead that is
+// also told whether the data source is over, just as a DataChunkDelimiterWithEOF is.
+type scannerDelimiter func([]byte, bool) (bool, []byte, []byte, int, error)
+
+// NewChunkScanner, builds a ChunkScanner reading the data source chunkSize bytes at a time and delimiting its chunks
+// with the given DataChunkDelimiter.
+func NewChunkScanner(dataSource io.Reader, chunkSize int, chunkDelimiter DataChunkDelimiter) *ChunkScanner {
+	return newChunkScanner(context.Background(), dataSource, chunkSize, 0, chunkDelimiter)
+}
+
+func newChunkScanner(
+	ctx context.Context,
+	dataSource io.Reader,
+	chunkSize int,
+	maxChunkSize int,
+	chunkDelimiter DataChunkDelimiter) *ChunkScanner {
+	infallibleDelimiter := func(chunk []byte) (bool, []byte, []byte, error) {
+		enough, chunkToBeProcessed, leftOver := chunkDelimiter(chunk)
+		return enough, chunkToBeProcessed, leftOver, nil
+	}
+
+	s := newChunkScannerWithError(ctx, dataSource, chunkSize, maxChunkSize, infallibleDelimiter)
+
+	// functions can not be compared, but the code they point to can, which tells whether the delimiter is one of the
+	// line delimiters of this package.
+	delimiterCode := reflect.ValueOf(chunkDelimiter).Pointer()
+
+	// the bufio.Reader panics on a negative read count, so it is turned into the same error the other delimiters get.
+	switch delimiterCode {
+	case reflect.ValueOf(DelimiteByNewLine).Pointer():
+		s.lines = bufio.NewReaderSize(nonNegativeReader{src: dataSource}, s.chunkSize)
+	case reflect.ValueOf(DelimiteByLine).Pointer():
+		s.lines = bufio.NewReaderSize(nonNegativeReader{src: dataSource}, s.chunkSize)
+		s.trimCarriageReturn = true
+	}
+
+	return s
+}
+
+func newChunkScannerWithError(
+	ctx context.Context,
+	dataSource io.Reader,
+	chunkSize int,
+	maxChunkSize int,
+	chunkDelimiter DataChunkDelimiterWithError) *ChunkScanner {
+	delimiterWithoutLookahead := func(chunk []byte) (bool, []byte, []byte, int, error) {
+		enough, chunkToBeProcessed, leftOver, err := chunkDelimiter(chunk)
+		return enough, chunkToBeProcessed, leftOver, 0, err
+	}
+
+	return newChunkScannerWithLookahead(ctx, dataSource, chunkSize, maxChunkSize, delimiterWithoutLookahead)
+}
+
+func newChunkScannerWithLookahead(
+	ctx context.Context,
+	dataSource io.Reader,
+	chunkSize int,
+	maxChunkSize int,
+	chunkDelimiter DataChunkDelimiterWithLookahead) *ChunkScanner {
+	delimiterNotToldOfEOF := func(chunk []byte, _ bool) (bool, []byte, []byte, int, error) {
+		return chunkDelimiter(chunk)
+	}
+
+	return newScanner(ctx, dataSource, chunkSize, maxChunkSize, delimiterNotToldOfEOF)
+}
+
+func newChunkScannerWithEOF(
+	ctx context.Context,
+	dataSource io.Reader,
+	chunkSize int,
+	maxChunkSize int,
+	chunkDelimiter DataChunkDelimiterWithEOF) *ChunkScanner {
+	delimiterWithoutLookahead := func(chunk []byte, atEOF bool) (bool, []byte, []byte, int, error) {
+		enough, chunkToBeProcessed, leftOver, err := chunkDelimiter(chunk, atEOF)
+		return enough, chunkToBeProcessed, leftOver, 0, err
+	}
+
+	s := newScanner(ctx, dataSource, chunkSize, maxChunkSize, delimiterWithoutLookahead)
+	s.delimitsAtEOF = true
+
+	return s
+}
+
+func newScanner(
+	ctx context.Context,
+	dataSource io.Reader,
+	chunkSize int,
+	maxChunkSize int,
+	chunkDelimiter scannerDelimiter) *ChunkScanner {
+	// reading into an empty chunk never moves the data source forward, so the processing would never end.
+	if chunkSize < 1 {
+		chunkSize = 1
+	}
+
+	return &ChunkScanner{
+		ctx:            ctx,
+		dataSource:     dataSource,
+		chunkSize:      chunkSize,
+		maxChunkSize:   maxChunkSize,
+		chunkDelimiter: chunkDelimiter,
+		leftOver:       make([]byte, 0),
+		buffer:         make([]byte, 0, chunkSize+1),
+	}
+}
+
+// remaining, returns the bytes already read from the data source that are not part of any chunk found so far.
+func (s *ChunkScanner) remaining() []byte {
+	if s.lines == nil {
+		return s.leftOver
+	}
+
+	buffered, _ := s.lines.Peek(s.lines.Buffered())
+
+	return append(append([]byte(nil), s.leftOver...), buffered...)
+}
+
+// Scan, advances the scanner to the next chunk, which is then available through Bytes, it returns "false" once the data
+// source is over or an error stopped the scanning, in which case Err tells which one it was.
+func (s *ChunkScanner) Scan() bool {
+	if s.done {
+		return false
+	}
+
+	chunk, err := s.nextChunk()
+
+	if err != nil || chunk == nil {
+		s.err = err
+		s.chunk = nil
+		s.done = true
+
+		return false
+	}
+
+	s.chunk = chunk
+	s.chunkOffset = s.consumed
+	s.chunkIndex++
+
+	// whatever was read and is not left over for the next chunks belongs to this one.
+	s.consumed = s.bytesRead - int64(len(s.leftOver))
+
+	return true
+}
+
+// Bytes, returns the chunk found by the last call to Scan.
+// NOTE: the chunk is only valid until the next call to Scan, it must be copied to be kept any longer.
+func (s *ChunkScanner) Bytes() []byte {
+	return s.chunk
+}
+
+// terminator, returns the bytes that terminated the chunk found by the last call to Scan, such as "\n" or "\r\n", which
+// are the ones collected right after the chunk and not left over for the next ones, empty when the chunk was ended by
+// the end of the data source, or nil when the delimiter did not hand the chunk back from the beginning of the data it
+// was given, in which case there is no telling where it ended.
+func (s *ChunkScanner) terminator() []byte {
+	chunkEnd := len(s.chunk)
+	terminatorEnd := len(s.buffer) - len(s.leftOver)
+
+	if s.lines != nil {
+		terminatorEnd = len(s.buffer)
+	}
+
+	if cap(s.chunk) == 0 || cap(s.buffer) == 0 || &s.chunk[:1][0] != &s.buffer[:1][0] || terminatorEnd < chunkEnd {
+		return nil
+	}
+
+	return s.buffer[chunkEnd:terminatorEnd]
+}
+
+// Err, returns the first error found while scanning, reaching the end of the data source is not an error, so it
+// returns nil in that case.
+func (s *ChunkScanner) Err() error {
+	return s.err
+}
+
+// nextChunk, reads the data source until the delimiter finds a complete chunk, or until the data source is over, and
+// returns it, nil is returned once there is no chunk left.
+func (s *ChunkScanner) nextChunk() ([]byte, error) {
+	// the chunk returned along with the EOF was the last one.
+	if s.eof {
+		return nil, nil
+	}
+
+	if s.lines != nil {
+		return s.nextLine()
+	}
+
+	var err error
+	enoughDataInChunkToBeProcessed := false
+	chunkToBeProcessed := s.buffer[:0]
+
+	// awaited, how much data the delimiter asked for before being called again, if it told so.
+	awaited := 0
+
+	// This loop is used to retrieve small parts of the data from the io.Reader then check if all the data fetched
+	// so far is enough to be considered a "chunk" by applying the DataChunkDelimiter function of the data so far
+	// collected every time a new part is retrieved.
+	for {
+		if err = s.ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		var tempChunk []byte
+		var readBuffer *[]byte
+
+		checkLeftOverFirst := len(s.leftOver) > 0
+
+		// whenever a new iteration begins, the left overs from the previous one has priority to be processed if
+		// they do exist.
+		if checkLeftOverFirst {
+			tempChunk = s.leftOver
+			s.leftOver = make([]byte, 0)
+		} else if s.sourceEOF {
+			err = io.EOF
+		} else {
+			// if there is no left over bytes from the previous iteration or it is the first one then the data
+			// source is read.
+			readBuffer = readBufferPool.Get().(*[]byte)
+
+			if cap(*readBuffer) < s.chunkSize {
+				*readBuffer = make([]byte, s.chunkSize)
+			}
+
+			tempChunk = (*readBuffer)[:s.chunkSize]
+
+			var bytesRead int
+			bytesRead, err = s.dataSource.Read(tempChunk)
+
+			// the io.Reader contract forbids negative counts, a reader returning one is broken and there is no way
+			// to tell which bytes of the chunk are valid.
+			if bytesRead < 0 {
+				readBufferPool.Put(readBuffer)
+				return nil, negativeReadCountError(bytesRead)
+			}
+
+			// readers are free to return less bytes than asked for, only those are data, the rest of the chunk is
+			// still zero filled.
+			tempChunk = tempChunk[:bytesRead]
+			s.bytesRead += int64(bytesRead)
+
+			// data sources are not read again once they hit an EOF, some of them, such as connections returning the
+			// last bytes along with the EOF, would block or return empty reads forever instead of another EOF.
+			if err == io.EOF {
+				s.sourceEOF = true
+			}
+		}
+
+		if len(tempChunk) > 0 {
+			chunkToBeProcessed = append(chunkToBeProcessed, tempChunk...)
+			s.buffer = chunkToBeProcessed
+		}
+
+		if readBuffer != nil {
+			readBufferPool.Put(readBuffer)
+		}
+
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+
+		// readers may return the last bytes of the data source along with the EOF, so whatever came back is
+		// delimited before stopping to read.
+		if len(tempChunk) > 0 && (len(chunkToBeProcessed) >= awaited || err == io.EOF) {
+			var lookahead int
+			var delimiterErr error
+			enoughDataInChunkToBeProcessed, chunkToBeProcessed, lookahead, delimiterErr =
+				s.delimit(chunkToBeProcessed, false)
+
+			if delimiterErr != nil {
+				return nil, delimiterErr
+			}
+
+			// whenever all the necessary data is retrieved in order to allow a processing of that chunk its time to
+			// process it, even at EOF the left overs will be processed in the next iterations before reading again.
+			if enoughDataInChunkToBeProcessed {
+				break
+			}
+
+			awaited = len(chunkToBeProcessed) + lookahead
+		}
+
+		if len(tempChunk) > 0 && s.maxChunkSize > 0 && len(chunkToBeProcessed) > s.maxChunkSize {
+			return nil, fmt.Errorf(
+				"%w: no chunk was delimited in [%d] bytes, the limit is [%d] bytes",
+				ErrChunkTooLarge,
+				len(chunkToBeProcessed),
+				s.maxChunkSize)
+		}
+
+		// once the reader hit an EOF, all the data collected so far is the last chunk to be processed, unless the
+		// delimiter is told about the EOF, in which case it is called once more to decide what that data is.
+		if err == io.EOF && s.delimitsAtEOF {
+			var delimiterErr error
+			enoughDataInChunkToBeProcessed, chunkToBeProcessed, _, delimiterErr = s.delimit(chunkToBeProcessed, true)
+
+			if delimiterErr != nil {
+				return nil, delimiterErr
+			}
+
+			if enoughDataInChunkToBeProcessed {
+				break
+			}
+		}
+
+		if err == io.EOF {
+			s.eof = true
+			break
+		}
+	}
+
+	// at EOF the data collected since the last delimited chunk, the last line of a file with no trailing new line
+	// for instance, was never recognized as a chunk by the delimiter, so a trailing new line terminating it is
+	// removed before flushing it as the last chunk, unless the delimiter already decided what that data is, and
+	// unless there is no data at all, in which case the previous chunk was already the last one.
+	if s.eof {
+		if !s.delimitsAtEOF {
+			chunkToBeProcessed = removeTrailingNewLine(chunkToBeProcessed)
+		}
+
+		if len(chunkToBeProcessed) == 0 {
+			return nil, nil
+		}
+
+		if s.strictTermination {
+			return nil, trailingDataError(chunkToBeProcessed)
+		}
+	}
+
+	if err = s.ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// an empty chunk is still a chunk, such as a blank line, it must not be mistaken for the end of the data source.
+	if chunkToBeProcessed == nil {
+		chunkToBeProcessed = []byte{}
+	}
+
+	return chunkToBeProcessed, nil
+}
+
+// delimit, hands the data collected to the delimiter, keeping the left over it returns for the next chunks, and returns
+// whether a chunk was found, the chunk, or the data given back, and how many more bytes the delimiter asked for.
+func (s *ChunkScanner) delimit(data []byte, atEOF bool) (bool, []byte, int, error) {
+	collected := len(data)
+	enough, chunk, leftOver, lookahead, err := s.chunkDelimiter(data, atEOF)
+
+	if err != nil {
+		return false, nil, 0, err
+	}
+
+	s.leftOver = leftOver
+
+	// the left over is delimited again before reading, so the delimiter would be given the same data again.
+	if enough && len(chunk) == 0 && len(leftOver) == collected {
+		return false, nil, 0, fmt.Errorf(
+			"%w: an empty chunk was found leaving all the [%d] bytes given over",
+			ErrNoProgress,
+			collected)
+	}
+
+	return enough, chunk, lookahead, nil
+}
+
+// timeDelimiter, wraps the delimiter of the scanner so the time taken by each one of its calls is handed to onCall, the
+// line delimiters are then called just as any other delimiter, since the bufio.Reader finding the lines never does.
+// NOTE: it must be called before the first Scan, while the bufio.Reader holds no data read yet.
+func (s *ChunkScanner) timeDelimiter(onCall func(time.Duration)) {
+	chunkDelimiter := s.chunkDelimiter
+	s.lines = nil
+
+	s.chunkDelimiter = func(data []byte, atEOF bool) (bool, []byte, []byte, int, error) {
+		start := time.Now()
+		enough, chunk, leftOver, lookahead, err := chunkDelimiter(data, atEOF)
+		onCall(time.Since(start))
+
+		return enough, chunk, leftOver, lookahead, err
+	}
+}
+
+// nextLine, same as nextChunk but for the line delimiters, the lines are found by the bufio.Reader, which scans every
+// byte read only once, instead of delimiting the whole data collected again after every read.
+func (s *ChunkScanner) nextLine() ([]byte, error) {
+	line := s.buffer[:0]
+
+	for {
+		if err := s.ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		part, err := s.lines.ReadSlice(newLineByte)
+
+		// the part returned is only valid until the next read, it is kept along with the rest of the line.
+		line = append(line, part...)
+		s.buffer = line
+		s.bytesRead += int64(len(part))
+
+		incomplete := err == bufio.ErrBufferFull || err == io.EOF
+
+		if incomplete && s.maxChunkSize > 0 && len(line) > s.maxChunkSize {
+			return nil, fmt.Errorf(
+				"%w: no chunk was delimited in [%d] bytes, the limit is [%d] bytes",
+				ErrChunkTooLarge,
+				len(line),
+				s.maxChunkSize)
+		}
+
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+
+		if err == io.EOF {
+			s.eof = true
+			s.sourceEOF = true
+
+			// just as for any other delimiter, the data after the last new line is the last chunk, if there is any.
+			if len(line) == 0 {
+				return nil, nil
+			}
+
+			if s.strictTermination {
+				return nil, trailingDataError(line)
+			}
+
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		line = line[:len(line)-1]
+
+		if s.trimCarriageReturn && len(line) > 0 && line[len(line)-1] == carriageReturnByte {
+			line = line[:len(line)-1]
+		}
+
+		break
+	}
+
+	if err := s.ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return line, nil
+}
+
+// trailingDataError, the error for the data left after the last delimited chunk in strict termination.
+func trailingDataError(trailingData []byte) error {
+	return fmt.Errorf("%w: [%d] bytes after the last delimited chunk", ErrTrailingData, len(trailingData))
+}
+
+// negativeReadCountError, the error for a data source returning a negative count from a read.
+func negativeReadCountError(n int) error {
+	return fmt.Errorf("data source returned an invalid negative read count [%d]", n)
+}
+
+// nonNegativeReader, an io.Reader returning an error instead of the negative count of a read of the underlying one,
+// for the readers that do not check the count themselves, such as the bufio.Reader, which panics on it instead.
+type nonNegativeReader struct {
+	src io.Reader
+}
+
+func (r nonNegativeReader) Read(p []byte) (int, error) {
+	n, err := r.src.Read(p)
+
+	if n < 0 {
+		return 0, negativeReadCountError(n)
+	}
+
+	return n, err
+}
@@ -0,0 +1,217 @@
+package filestream
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var errInvalidLastLine = errors.New("invalid last line")
+
+func TestDataChunkDelimiterWithEOF(t *testing.T) {
+	// upperLastLine, delimits lines and turns the last one into upper case once the data source is over, giving it back
+	// as data never delimited when it is not terminated by a "!", and failing when it starts with a "?".
+	upperLastLine := func(chunk []byte, atEOF bool) (bool, []byte, []byte, error) {
+		if enough, line, leftOver := DelimiteByNewLine(chunk); enough {
+			return true, line, leftOver, nil
+		}
+
+		if !atEOF || len(chunk) == 0 {
+			return false, chunk, nil, nil
+		}
+
+		if strings.HasPrefix(string(chunk), "?") {
+			return false, nil, nil, errInvalidLastLine
+		}
+
+		last := []byte(strings.ToUpper(string(chunk)))
+
+		return strings.HasSuffix(string(chunk), "!"), last, nil, nil
+	}
+
+	tests := []struct {
+		name    string
+		data    string
+		strict  bool
+		want    []string
+		wantErr error
+	}{
+		{"terminated last line", "a\nb\n", false, []string{"a", "b"}, nil},
+		{"last line", "a\nb", false, []string{"a", "B"}, nil},
+		{"delimited last line", "a\nb!", true, []string{"a", "B!"}, nil},
+		{"trailing data", "a\nb", true, []string{"a"}, ErrTrailingData},
+		{"invalid last line", "a\n?b", false, []string{"a"}, errInvalidLastLine},
+		{"empty", "", true, []string{}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := collectChunks(t, tt.data, func() []Option {
+				return []Option{WithDelimiterWithEOF(upperLastLine), WithStrictTermination(tt.strict)}
+			})
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// shortReader, an io.Reader returning at most maxRead bytes per read, as network streams often do, and zeroing the rest
+// of the buffer it was given, so any byte used past the count returned shows up as a NUL.
+type shortReader struct {
+	src     io.Reader
+	maxRead int
+}
+
+func (r *shortReader) Read(p []byte) (int, error) {
+	limit := len(p)
+
+	if limit > r.maxRead {
+		limit = r.maxRead
+	}
+
+	n, err := r.src.Read(p[:limit])
+
+	for i := n; i < len(p); i++ {
+		p[i] = 0
+	}
+
+	return n, err
+}
+
+func TestShortReads(t *testing.T) {
+	data := `{"id":1}` + "\n" + `{"id":2,"name":"second"}` + "\n" + `{"id":3}`
+	want := []string{`{"id":1}`, `{"id":2,"name":"second"}`, `{"id":3}`}
+
+	delimiters := []struct {
+		name           string
+		chunkDelimiter DataChunkDelimiter
+	}{
+		{"new line", DelimiteByNewLine},
+		{"separator", DelimiteBySeparator([]byte("\n"))},
+	}
+
+	for _, delimiter := range delimiters {
+		for _, maxRead := range []int{1, 3, 5} {
+			for _, chunkSize := range []int{4, 16, 128} {
+				name := fmt.Sprintf("%s, reads of [%d] bytes, chunk size [%d]", delimiter.name, maxRead, chunkSize)
+
+				t.Run(name, func(t *testing.T) {
+					var got []string
+
+					err := ProcessDataSourceInChunks(
+						&shortReader{src: strings.NewReader(data), maxRead: maxRead},
+						chunkSize,
+						func(chunk []byte) error {
+							if bytes.IndexByte(chunk, 0) >= 0 {
+								t.Errorf("chunk %q holds a NUL byte", chunk)
+							}
+
+							got = append(got, string(chunk))
+
+							return nil
+						},
+						delimiter.chunkDelimiter)
+
+					if err != nil {
+						t.Fatalf("unexpected error: %v", err)
+					}
+
+					if !reflect.DeepEqual(got, want) {
+						t.Errorf("got %q, want %q", got, want)
+					}
+				})
+			}
+		}
+	}
+}
+
+// negativeCountReader, a broken io.Reader returning a negative count after the data it holds was read.
+type negativeCountReader struct {
+	src io.Reader
+}
+
+func (r *negativeCountReader) Read(p []byte) (int, error) {
+	if n, err := r.src.Read(p); n > 0 {
+		return n, err
+	}
+
+	return -1, nil
+}
+
+func TestLineDelimitersNegativeReadCount(t *testing.T) {
+	for _, chunkDelimiter := range []DataChunkDelimiter{DelimiteByNewLine, DelimiteByLine} {
+		var got []string
+
+		err := ProcessDataSourceInChunks(
+			&negativeCountReader{src: strings.NewReader("first\nsecond")},
+			8,
+			func(chunk []byte) error {
+				got = append(got, string(chunk))
+				return nil
+			},
+			chunkDelimiter)
+
+		if err == nil || !strings.Contains(err.Error(), "invalid negative read count [-1]") {
+			t.Errorf("got error [%v], want the negative read count", err)
+		}
+
+		if want := []string{"first"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	}
+}
+
+func BenchmarkReadBuffers(b *testing.B) {
+	// any delimiter but the line ones, which are left to the bufio.Reader and never use the read buffers.
+	separator := DelimiteBySeparator([]byte("\n"))
+
+	// unpooled, the buffer put in the pool in place of the ones read into, with no memory, so every read allocates.
+	unpooled := new([]byte)
+
+	delimiters := []struct {
+		name           string
+		chunkDelimiter DataChunkDelimiter
+	}{
+		{"pooled", separator},
+		{
+			// the delimiter is called right after the read buffer is given back, so replacing it in the pool by one
+			// with no memory leaves the next read to allocate a buffer of its own, just as every read did before the
+			// buffers were pooled.
+			"allocated for every read",
+			func(chunk []byte) (bool, []byte, []byte) {
+				readBufferPool.Get()
+				*unpooled = nil
+				readBufferPool.Put(unpooled)
+
+				return separator(chunk)
+			},
+		},
+	}
+
+	for _, delimiter := range delimiters {
+		b.Run(delimiter.name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(shortLines)))
+
+			for i := 0; i < b.N; i++ {
+				err := ProcessDataSourceInChunks(bytes.NewReader(shortLines), 64, func([]byte) error {
+					return nil
+				}, delimiter.chunkDelimiter)
+
+				if err != nil {
+					b.Fatalf("unexpected error: %v", err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package filestream
+
+import (
+	"bytes"
+	"errors"
+	"io"
+)
+
+var errStreamsDiffer = errors.New("streams differ")
+
+// CompareStreams, reads both data sources in lockstep, delimiting them with the same DataChunkDelimiter, and compares
+// them chunk by chunk, such as records of two NDJSON files in a regression test. It returns whether both have exactly
+// the same chunks and, when they do not, the index, starting at zero, of the first chunk that differs, otherwise -1.
+// Data sources of different lengths differ at the index of the first chunk missing in the shorter one.
+// NOTE: the delimiter is used for both data sources at the same time, so it must not hold any state about the data,
+// which rules out delimiters such as the one built by DelimiteByJSONAuto.
+func CompareStreams(a, b io.Reader, chunkDelimiter DataChunkDelimiter) (bool, int, error) {
+	chunksOfB := make(chan []byte)
+	resultOfB := make(chan error, 1)
+	done := make(chan struct{})
+
+	// the chunks of "b" are produced in another goroutine, one at a time, as the chunks of "a" are compared.
+	go func() {
+		chunkHandler := func(chunk []byte) error {
+			select {
+			case chunksOfB <- append([]byte(nil), chunk...):
+				return nil
+			case <-done:
+				return errStreamsDiffer
+			}
+		}
+
+		err := ProcessDataSourceInChunks(b, defaultChunkSize, chunkHandler, chunkDelimiter)
+		close(chunksOfB)
+		resultOfB <- err
+	}()
+
+	defer close(done)
+
+	index := 0
+	differAt := -1
+
+	// errOfB, the error that stopped the processing of "b" before it was over, if any.
+	var errOfB error
+
+	chunkHandler := func(chunk []byte) error {
+		other, found := <-chunksOfB
+
+		// "b" is over before "a" is, unless it failed.
+		if !found {
+			if errOfB = <-resultOfB; errOfB != nil {
+				return errOfB
+			}
+		}
+
+		if !found || !bytes.Equal(chunk, other) {
+			differAt = index
+			return errStreamsDiffer
+		}
+
+		index++
+
+		return nil
+	}
+
+	err := ProcessDataSourceInChunks(a, defaultChunkSize, chunkHandler, chunkDelimiter)
+
+	if errOfB != nil {
+		return false, -1, errOfB
+	}
+
+	if differAt >= 0 {
+		return false, differAt, nil
+	}
+
+	if err != nil {
+		return false, -1, err
+	}
+
+	// "a" is over, so "b" is either over too or longer than it.
+	if _, found := <-chunksOfB; found {
+		return false, index, nil
+	}
+
+	if err = <-resultOfB; err != nil {
+		return false, -1, err
+	}
+
+	return true, -1, nil
+}
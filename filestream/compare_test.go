@@ -0,0 +1,79 @@
+package filestream
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// failingReader, a data source returning its data and then failing with err instead of an io.EOF.
+type failingReader struct {
+	data io.Reader
+	err  error
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	n, err := r.data.Read(p)
+
+	if err == io.EOF {
+		return n, r.err
+	}
+
+	return n, err
+}
+
+func TestCompareStreams(t *testing.T) {
+	tests := []struct {
+		name      string
+		a         string
+		b         string
+		wantEqual bool
+		wantIndex int
+	}{
+		{"equal", "a\nb\nc\n", "a\nb\nc\n", true, -1},
+		{"equal without trailing new line", "a\nb\nc", "a\nb\nc\n", true, -1},
+		{"different chunk", "a\nb\nc\n", "a\nx\nc\n", false, 1},
+		{"shorter a", "a\nb\n", "a\nb\nc\n", false, 2},
+		{"shorter b", "a\nb\nc\n", "a\nb\n", false, 2},
+		{"both empty", "", "", true, -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			equal, index, err := CompareStreams(strings.NewReader(tt.a), strings.NewReader(tt.b), DelimiteByNewLine)
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if equal != tt.wantEqual || index != tt.wantIndex {
+				t.Errorf("got (%v, %d), want (%v, %d)", equal, index, tt.wantEqual, tt.wantIndex)
+			}
+		})
+	}
+}
+
+func TestCompareStreamsReadError(t *testing.T) {
+	errRead := errors.New("read failed")
+
+	tests := []struct {
+		name string
+		a    io.Reader
+		b    io.Reader
+	}{
+		{"error in a", &failingReader{strings.NewReader("a\n"), errRead}, strings.NewReader("a\nb\nc\n")},
+		{"error in b", strings.NewReader("a\nb\nc\n"), &failingReader{strings.NewReader("a\n"), errRead}},
+		{"error in b after a is over", strings.NewReader("a\n"), &failingReader{strings.NewReader("a\n"), errRead}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			equal, index, err := CompareStreams(tt.a, tt.b, DelimiteByNewLine)
+
+			if !errors.Is(err, errRead) || equal || index != -1 {
+				t.Errorf("got (%v, %d, %v), want (false, -1, %v)", equal, index, err, errRead)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package filestream
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// readDeadliner, the readers whose blocked reads can be interrupted by a deadline, such as net.Conn or os.File for pipes.
+type readDeadliner interface {
+	SetReadDeadline(time.Time) error
+}
+
+// contextReader, an io.Reader that stops reading from the underlying one as soon as the context is done, returning the
+// error of the context. Whenever the underlying reader supports read deadlines a read blocked in it is interrupted
+// right away, by moving its deadline to the past, otherwise the context is only noticed once the read returns.
+// NOTE: the read deadline is left in the past after the context is done, the underlying reader is not expected to be
+// read again afterwards.
+type contextReader struct {
+	ctx context.Context
+	src io.Reader
+}
+
+func newContextReader(ctx context.Context, src io.Reader) io.Reader {
+	return &contextReader{ctx: ctx, src: src}
+}
+
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	deadliner, ok := r.src.(readDeadliner)
+
+	if !ok {
+		return r.src.Read(p)
+	}
+
+	readDone := make(chan struct{})
+	watcherDone := make(chan struct{})
+
+	go func() {
+		defer close(watcherDone)
+
+		select {
+		case <-r.ctx.Done():
+			deadliner.SetReadDeadline(time.Now())
+		case <-readDone:
+		}
+	}()
+
+	n, err := r.src.Read(p)
+	close(readDone)
+	<-watcherDone
+
+	// the read may have failed because of the deadline set once the context was done, which is reported as the error
+	// of the context instead.
+	if err != nil {
+		if ctxErr := r.ctx.Err(); ctxErr != nil {
+			return n, ctxErr
+		}
+	}
+
+	return n, err
+}
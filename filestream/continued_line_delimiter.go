@@ -0,0 +1,59 @@
+package filestream
+
+// DelimiteByContinuedLine, builds a DataChunkDelimiterWithEOF for shell or Makefile like data, where a line ending with
+// the continuation byte, usually "\", goes on in the next line, every logical line is emitted as a single chunk with
+// the continuation bytes and the new lines that follow them removed. A line is only known to be complete once a new
+// line not preceded by the continuation byte is found, so a continuation cut by the end of a read waits for more data,
+// while the data after the last new line, joined just the same, is the last chunk once the data source ends.
+func DelimiteByContinuedLine(continuation byte) DataChunkDelimiterWithEOF {
+	return func(chunk []byte, atEOF bool) (bool, []byte, []byte, error) {
+		if len(chunk) == 0 {
+			return false, chunk, nil, nil
+		}
+
+		line := make([]byte, 0, len(chunk))
+		start := 0
+		terminated := true
+
+		for {
+			// a continuation byte right before the end of the data source continues into nothing.
+			if atEOF && start >= len(chunk) {
+				terminated = false
+				break
+			}
+
+			lineEnd := indexOfByteFrom(chunk, start, newLineByte)
+
+			if lineEnd < 0 && !atEOF {
+				return false, chunk, nil, nil
+			}
+
+			// the last physical line ends with the data source.
+			if lineEnd < 0 {
+				lineEnd = len(chunk)
+				terminated = false
+			}
+
+			physicalLine := chunk[start:lineEnd]
+			start = lineEnd + 1
+
+			if len(physicalLine) == 0 || physicalLine[len(physicalLine)-1] != continuation {
+				line = append(line, physicalLine...)
+				break
+			}
+
+			line = append(line, physicalLine[:len(physicalLine)-1]...)
+		}
+
+		// the last line of the data source has no new line after it.
+		if start > len(chunk) {
+			start = len(chunk)
+		}
+
+		leftOver := make([]byte, len(chunk)-start)
+		copy(leftOver, chunk[start:])
+
+		// a line ended by the data source is not terminated, but it is handed over joined rather than as it was read.
+		return terminated, line, leftOver, nil
+	}
+}
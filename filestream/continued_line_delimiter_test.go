@@ -0,0 +1,38 @@
+package filestream
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDelimiteByContinuedLine(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []string
+	}{
+		{"plain lines", "a\nb\n", []string{"a", "b"}},
+		{"continued line", "a\\\nb\nc\n", []string{"ab", "c"}},
+		{"many continuations", "a\\\nb\\\nc\n", []string{"abc"}},
+		{"no trailing new line", "x\na\\\nb", []string{"x", "ab"}},
+		{"continuation at the end", "a\\\nb\\", []string{"ab"}},
+		{"blank line", "a\n\nb", []string{"a", "", "b"}},
+		{"empty", "", []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := collectChunks(t, tt.data, withDelimiterWithEOF(func() DataChunkDelimiterWithEOF {
+				return DelimiteByContinuedLine('\\')
+			}))
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
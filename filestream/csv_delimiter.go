@@ -0,0 +1,41 @@
+package filestream
+
+// DelimiteByCSVRecord, builds a DataChunkDelimiter for RFC 4180 CSV, where a field enclosed in the quote byte may hold
+// new lines, so a record only ends at a new line found outside of any quoted field. A doubled quote inside a quoted
+// field, the escaped form of a literal quote, closes and opens the field right away, so it never ends it. Just as in
+// DelimiteByLine, a carriage return right before the new line is part of the terminator. The record is emitted as it
+// was found, quotes included, to be parsed into fields by encoding/csv or any other CSV reader. A record cut by the end
+// of a read is scanned again from its beginning once more data arrives, so whether a new line is quoted is always told
+// from the whole record.
+func DelimiteByCSVRecord(quote byte) DataChunkDelimiter {
+	return func(chunk []byte) (bool, []byte, []byte) {
+		quoted := false
+		recordEnd := -1
+
+		for i := 0; i < len(chunk) && recordEnd < 0; i++ {
+			switch chunk[i] {
+			case quote:
+				quoted = !quoted
+			case newLineByte:
+				if !quoted {
+					recordEnd = i
+				}
+			}
+		}
+
+		if recordEnd < 0 {
+			return false, chunk, nil
+		}
+
+		leftOver := make([]byte, len(chunk)-recordEnd-1)
+		copy(leftOver, chunk[recordEnd+1:])
+
+		record := chunk[:recordEnd]
+
+		if len(record) > 0 && record[len(record)-1] == carriageReturnByte {
+			record = record[:len(record)-1]
+		}
+
+		return true, record, leftOver
+	}
+}
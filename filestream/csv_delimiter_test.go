@@ -0,0 +1,114 @@
+package filestream
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestDelimiteByCSVRecord(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []string
+	}{
+		{"plain records", "a,b\nc,d\n", []string{"a,b", "c,d"}},
+		{"quoted new line", "\"a\nb\",c\nd,e\n", []string{"\"a\nb\",c", "d,e"}},
+		{"escaped quote", "\"a\"\"\nb\",c\nd\n", []string{"\"a\"\"\nb\",c", "d"}},
+		{"carriage return", "a,b\r\nc\r\n", []string{"a,b", "c"}},
+		{"no trailing new line", "a\n\"b\nc\"", []string{"a", "\"b\nc\""}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := collectChunks(t, tt.data, withDelimiter(func() DataChunkDelimiter {
+				return DelimiteByCSVRecord('"')
+			}))
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestDelimiteByCSVRecordWithLookahead, a CSV delimiter asking for more data while a quoted field is open finds the
+// same records as DelimiteByCSVRecord with less calls, since it is not called again after every read.
+func TestDelimiteByCSVRecordWithLookahead(t *testing.T) {
+	data := "\"a long field\nspanning\nmany reads\",1\nb,2\n"
+	plainCalls := 0
+	lookaheadCalls := 0
+
+	csvRecord := DelimiteByCSVRecord('"')
+
+	plain := func(chunk []byte) (bool, []byte, []byte) {
+		plainCalls++
+		return csvRecord(chunk)
+	}
+
+	lookahead := func(chunk []byte) (bool, []byte, []byte, int, error) {
+		lookaheadCalls++
+		enough, record, leftOver := csvRecord(chunk)
+
+		// an open quoted field is not going to end in the next few bytes, so they are asked for at once.
+		if !enough && bytes.Count(chunk, []byte{'"'})%2 == 1 {
+			return false, record, nil, 8, nil
+		}
+
+		return enough, record, leftOver, 0, nil
+	}
+
+	want, err := collectChunks(t, data, withDelimiter(func() DataChunkDelimiter { return plain }))
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got, err := collectChunks(t, data, func() []Option {
+		return []Option{WithDelimiterWithLookahead(lookahead)}
+	})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+
+	if lookaheadCalls >= plainCalls {
+		t.Errorf("the lookahead delimiter was called [%d] times, the plain one [%d] times", lookaheadCalls, plainCalls)
+	}
+}
+
+func TestDelimiterOptionsReplaceEachOther(t *testing.T) {
+	lookahead := func(chunk []byte) (bool, []byte, []byte, int, error) {
+		return false, chunk, nil, 0, nil
+	}
+
+	withError := func(chunk []byte) (bool, []byte, []byte, error) {
+		return false, chunk, nil, nil
+	}
+
+	p := NewProcessor(nil, WithDelimiterWithLookahead(lookahead), WithDelimiterWithError(withError))
+
+	if p.chunkDelimiter != nil || p.chunkDelimiterWithError == nil || p.chunkDelimiterWithLookahead != nil {
+		t.Errorf("WithDelimiterWithError did not replace the delimiter set before it")
+	}
+
+	p = NewProcessor(nil, WithDelimiterWithError(withError), WithDelimiterWithLookahead(lookahead))
+
+	if p.chunkDelimiter != nil || p.chunkDelimiterWithError != nil || p.chunkDelimiterWithLookahead == nil {
+		t.Errorf("WithDelimiterWithLookahead did not replace the delimiter set before it")
+	}
+
+	p = NewProcessor(nil, WithDelimiterWithLookahead(lookahead), WithDelimiter(DelimiteByLine))
+
+	if p.chunkDelimiter == nil || p.chunkDelimiterWithError != nil || p.chunkDelimiterWithLookahead != nil {
+		t.Errorf("WithDelimiter did not replace the delimiter set before it")
+	}
+}
@@ -0,0 +1,300 @@
+package filestream
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"encoding/binary"
+	"io"
+	"mime/quotedprintable"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/krolaw/zipstream"
+	"github.com/pierrec/lz4/v4"
+)
+
+// DecompressorFactory, function that wraps a compressed data source into a reader of its decompressed content.
+type DecompressorFactory func(io.Reader) (io.Reader, error)
+
+var (
+	decompressorsMutex sync.RWMutex
+
+	// decompressors, the registry of DecompressorFactory by file extension used by OpenDecompressed, more of them can
+	// be added with RegisterDecompressor.
+	decompressors = map[string]DecompressorFactory{
+		".gz":  NewGzipSource,
+		".zst": NewZstdSource,
+		".bz2": func(r io.Reader) (io.Reader, error) {
+			return NewBzip2Source(r), nil
+		},
+		".zip": func(r io.Reader) (io.Reader, error) {
+			// just like ProcessFirstZipEntry, only the first entry of the archive is read.
+			zipStreamData := zipstream.NewReader(r)
+			_, err := zipStreamData.Next()
+
+			return zipStreamData, err
+		},
+		".sz": func(r io.Reader) (io.Reader, error) {
+			return NewSnappySource(r), nil
+		},
+		".lz4": func(r io.Reader) (io.Reader, error) {
+			return NewLZ4Source(r), nil
+		},
+	}
+)
+
+// magicPeekSize, the number of leading bytes OpenAutoDetect peeks from the data source, which is the size of the longest
+// magic number it knows.
+const magicPeekSize = 10
+
+// compressionMagics, the magic numbers recognized by OpenAutoDetect and the extension of the decompressor registered
+// for each one of them.
+var compressionMagics = []struct {
+	magic     []byte
+	extension string
+}{
+	{magic: []byte{0x1f, 0x8b}, extension: ".gz"},
+	{magic: []byte("PK\x03\x04"), extension: ".zip"},
+	{magic: []byte("BZh"), extension: ".bz2"},
+	{magic: []byte{0x04, 0x22, 0x4d, 0x18}, extension: ".lz4"},
+	{magic: []byte("\xff\x06\x00\x00sNaPpY"), extension: ".sz"},
+	{magic: []byte{0x28, 0xb5, 0x2f, 0xfd}, extension: ".zst"},
+}
+
+// NewGzipSource, wraps a data source compressed with gzip so its decompressed content can be handed straight to
+// ProcessDataSourceInChunks, an error is returned right away when the data source does not start with a valid gzip
+// header. The gzip reader is closed once its content is over, and a truncated or corrupt content surfaces as the error
+// of the read that found it, which the processing returns as any other read error.
+// NOTE: the source returned is read decompressed, so along with WithProgressOf and the size found by
+// GzipUncompressedSize, or any other size known in advance, the progress is told in decompressed bytes.
+func NewGzipSource(dataSource io.Reader) (io.Reader, error) {
+	gzipReader, err := gzip.NewReader(dataSource)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &gzipSource{reader: gzipReader}, nil
+}
+
+// gzipSizeFieldLength, the length of the ISIZE field at the very end of a gzip file, holding the size of its
+// uncompressed content.
+const gzipSizeFieldLength = 4
+
+// GzipUncompressedSize, returns the size of the uncompressed content of a gzip data source as told by its footer, such
+// as to report the progress of its decompression, the data source is seeked back to where it was before returning.
+// NOTE: the footer only keeps the size modulo 4 GiB and only for the last member of the file, so it is only exact for
+// single member files smaller than that.
+func GzipUncompressedSize(dataSource io.ReadSeeker) (int64, error) {
+	current, err := dataSource.Seek(0, io.SeekCurrent)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err = dataSource.Seek(-gzipSizeFieldLength, io.SeekEnd); err != nil {
+		return 0, err
+	}
+
+	sizeField := make([]byte, gzipSizeFieldLength)
+	_, err = io.ReadFull(dataSource, sizeField)
+
+	if _, seekErr := dataSource.Seek(current, io.SeekStart); err == nil {
+		err = seekErr
+	}
+
+	if err != nil {
+		return 0, err
+	}
+
+	return int64(binary.LittleEndian.Uint32(sizeField)), nil
+}
+
+// gzipSource, the decompressed content of a gzip data source, which closes the gzip reader as soon as a read fails or
+// hits the end of the content.
+type gzipSource struct {
+	reader *gzip.Reader
+	closed bool
+}
+
+func (g *gzipSource) Read(p []byte) (int, error) {
+	n, err := g.reader.Read(p)
+
+	if err != nil && !g.closed {
+		g.closed = true
+
+		if closeErr := g.reader.Close(); closeErr != nil && err == io.EOF {
+			err = closeErr
+		}
+	}
+
+	return n, err
+}
+
+// NewBzip2Source, wraps a data source compressed with bzip2 so its decompressed content can be handed straight to
+// ProcessDataSourceInChunks, an invalid or corrupt content surfaces as the error of the read that found it.
+// NOTE: the bzip2 reader only decompresses and holds no resource, so unlike the gzip one it has nothing to be closed.
+func NewBzip2Source(dataSource io.Reader) io.Reader {
+	return bzip2.NewReader(dataSource)
+}
+
+// NewZstdSource, wraps a data source compressed with zstd so its decompressed content can be handed straight to
+// ProcessDataSourceInChunks, just as NewGzipSource does for gzip, the decoder is released once its content is over or a
+// read fails.
+func NewZstdSource(dataSource io.Reader) (io.Reader, error) {
+	zstdReader, err := zstd.NewReader(dataSource)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &zstdSource{reader: zstdReader}, nil
+}
+
+// zstdSource, the decompressed content of a zstd data source, which closes the decoder, stopping the goroutines it
+// decodes with, as soon as a read fails or hits the end of the content.
+// The decoder can not be read once it is closed, so the error that closed it is returned by any read after it.
+type zstdSource struct {
+	reader *zstd.Decoder
+	err    error
+}
+
+func (z *zstdSource) Read(p []byte) (int, error) {
+	if z.err != nil {
+		return 0, z.err
+	}
+
+	n, err := z.reader.Read(p)
+
+	if err != nil {
+		z.err = err
+		z.reader.Close()
+	}
+
+	return n, err
+}
+
+// Close, releases the decoder before its content is over, such as when the file opened by OpenDecompressed is closed
+// early.
+func (z *zstdSource) Close() error {
+	if z.err == nil {
+		z.err = zstd.ErrDecoderClosed
+		z.reader.Close()
+	}
+
+	return nil
+}
+
+// NewSnappySource, wraps a data source compressed with the Snappy framing format so its decompressed content can be
+// handed straight to ProcessDataSourceInChunks.
+func NewSnappySource(dataSource io.Reader) io.Reader {
+	return snappy.NewReader(dataSource)
+}
+
+// NewLZ4Source, wraps a data source compressed with the LZ4 frame format so its decompressed content can be handed
+// straight to ProcessDataSourceInChunks.
+func NewLZ4Source(dataSource io.Reader) io.Reader {
+	return lz4.NewReader(dataSource)
+}
+
+// NewQuotedPrintableSource, wraps a data source encoded as quoted-printable, such as an email body, decoding it before
+// it is delimited, so soft line breaks ("=" at the end of a line) join the lines they split and every "=XX" sequence is
+// turned into the byte it stands for.
+func NewQuotedPrintableSource(dataSource io.Reader) io.Reader {
+	return quotedprintable.NewReader(dataSource)
+}
+
+// RegisterDecompressor, registers the DecompressorFactory used by OpenDecompressed for files with the given extension,
+// replacing any previous one, the extension is expected with its leading dot (e.g. ".zst") and is case insensitive.
+func RegisterDecompressor(extension string, factory DecompressorFactory) {
+	decompressorsMutex.Lock()
+	defer decompressorsMutex.Unlock()
+
+	decompressors[strings.ToLower(extension)] = factory
+}
+
+// decompressedFile, the decompressed content of an opened file, closing it closes both the decompressor, when it can
+// be closed, and the file itself.
+type decompressedFile struct {
+	io.Reader
+	file *os.File
+}
+
+func (d *decompressedFile) Close() error {
+	if closer, ok := d.Reader.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			d.file.Close()
+			return err
+		}
+	}
+
+	return d.file.Close()
+}
+
+// OpenDecompressed, opens the file in the given path and wraps it with the decompressor registered for its extension,
+// files with an extension with no decompressor registered are read as they are.
+func OpenDecompressed(path string) (io.ReadCloser, error) {
+	file, err := os.Open(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	decompressorsMutex.RLock()
+	factory, found := decompressors[strings.ToLower(filepath.Ext(path))]
+	decompressorsMutex.RUnlock()
+
+	if !found {
+		return file, nil
+	}
+
+	reader, err := factory(file)
+
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return &decompressedFile{Reader: reader, file: file}, nil
+}
+
+// OpenAutoDetect, wraps the data source with the decompressor its leading bytes call for, regardless of any file name,
+// gzip ("1f 8b"), zip ("PK\x03\x04", only the first entry is read), bzip2 ("BZh"), zstd ("28 b5 2f fd"), LZ4 and
+// Snappy framing are recognized and decompressed by the decompressors registered for their extensions, anything else,
+// data sources shorter than the magic numbers included, is returned as plain data. Up to magicPeekSize bytes are read
+// to tell the format, those are kept in memory and read again before the rest of the data source, so nothing is lost
+// even when the data source can not be seeked.
+func OpenAutoDetect(dataSource io.Reader) (io.Reader, error) {
+	peeked := make([]byte, magicPeekSize)
+	n, err := io.ReadFull(dataSource, peeked)
+
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+
+	peeked = peeked[:n]
+	source := io.MultiReader(bytes.NewReader(peeked), dataSource)
+
+	for _, m := range compressionMagics {
+		if !bytes.HasPrefix(peeked, m.magic) {
+			continue
+		}
+
+		decompressorsMutex.RLock()
+		factory, found := decompressors[m.extension]
+		decompressorsMutex.RUnlock()
+
+		if !found {
+			break
+		}
+
+		return factory(source)
+	}
+
+	return source, nil
+}
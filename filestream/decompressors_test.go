@@ -0,0 +1,146 @@
+package filestream
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/klauspost/compress/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+)
+
+const decompressedContent = "a\nb\nc\n"
+
+// gzipped, the content compressed with gzip.
+func gzipped(t *testing.T, content string) []byte {
+	t.Helper()
+
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	_, _ = writer.Write([]byte(content))
+
+	if err := writer.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return compressed.Bytes()
+}
+
+// zstdCompressed, the content compressed with zstd.
+func zstdCompressed(t *testing.T, content string) []byte {
+	t.Helper()
+
+	var compressed bytes.Buffer
+	writer, err := zstd.NewWriter(&compressed)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, _ = writer.Write([]byte(content))
+
+	if err = writer.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return compressed.Bytes()
+}
+
+func TestOpenDecompressed(t *testing.T) {
+	dir := t.TempDir()
+
+	RegisterDecompressor(".TEST", func(r io.Reader) (io.Reader, error) {
+		return io.MultiReader(bytes.NewReader([]byte("custom\n")), r), nil
+	})
+
+	tests := []struct {
+		name    string
+		content []byte
+		want    string
+	}{
+		{"data.gz", gzipped(t, decompressedContent), decompressedContent},
+		{"data.zst", zstdCompressed(t, decompressedContent), decompressedContent},
+		{"data.test", []byte(decompressedContent), "custom\n" + decompressedContent},
+		{"data.txt", []byte(decompressedContent), decompressedContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.name)
+
+			if err := os.WriteFile(path, tt.content, 0600); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			reader, err := OpenDecompressed(path)
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			defer reader.Close()
+
+			got, err := io.ReadAll(reader)
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if string(got) != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpenAutoDetect(t *testing.T) {
+	var snappyCompressed bytes.Buffer
+	snappyWriter := snappy.NewBufferedWriter(&snappyCompressed)
+	_, _ = snappyWriter.Write([]byte(decompressedContent))
+	_ = snappyWriter.Close()
+
+	var lz4Compressed bytes.Buffer
+	lz4Writer := lz4.NewWriter(&lz4Compressed)
+	_, _ = lz4Writer.Write([]byte(decompressedContent))
+	_ = lz4Writer.Close()
+
+	tests := []struct {
+		name  string
+		input []byte
+		want  string
+	}{
+		{"gzip", gzipped(t, decompressedContent), decompressedContent},
+		{"zip", zipArchive(t, "data.txt", decompressedContent), decompressedContent},
+		{"zstd", zstdCompressed(t, decompressedContent), decompressedContent},
+		{"snappy", snappyCompressed.Bytes(), decompressedContent},
+		{"lz4", lz4Compressed.Bytes(), decompressedContent},
+		{"plain text", []byte(decompressedContent), decompressedContent},
+		{"shorter than the magic numbers", []byte{0x1f}, "\x1f"},
+		{"empty", []byte{}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// the data source is not seekable, so the bytes peeked can only be read again from memory.
+			reader, err := OpenAutoDetect(io.MultiReader(bytes.NewReader(tt.input)))
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			got, err := io.ReadAll(reader)
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if string(got) != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package filestream
+
+import "io"
+
+// DataChunkDelimiterSelector, function that handles a chunk just like a DataChunkHandler does and also returns the
+// DataChunkDelimiter to be used to find the next chunk, or nil to keep using the current one.
+type DataChunkDelimiterSelector func([]byte) (DataChunkDelimiter, error)
+
+// ProcessDataSourceInChunksSelectingDelimiter, same as ProcessDataSourceInChunks but for self describing formats,
+// where a record determinates the framing of the next one, the first chunk is found by the firstDelimiter and from then
+// on each chunk is found by the DataChunkDelimiter returned by the selector when handling the previous chunk.
+func ProcessDataSourceInChunksSelectingDelimiter(
+	dataSource io.Reader,
+	chunkSize int,
+	firstDelimiter DataChunkDelimiter,
+	selector DataChunkDelimiterSelector) error {
+	current := firstDelimiter
+
+	chunkDelimiter := func(chunk []byte) (bool, []byte, []byte) {
+		return current(chunk)
+	}
+
+	// the handler always runs before the engine delimits the next chunk, so the delimiter it selects is the one
+	// applied to the bytes that follow the chunk just handled.
+	chunkHandler := func(b []byte) error {
+		next, err := selector(b)
+
+		if err != nil {
+			return err
+		}
+
+		if next != nil {
+			current = next
+		}
+
+		return nil
+	}
+
+	return ProcessDataSourceInChunks(dataSource, chunkSize, chunkHandler, chunkDelimiter)
+}
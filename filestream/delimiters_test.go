@@ -0,0 +1,245 @@
+package filestream
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// largeChunkSize, a chunk size holding the whole data of every test at once.
+const largeChunkSize = 1 << 16
+
+// runWithChunkSize, processes the data with a Processor built by the options newOptions returns and the chunk size
+// given, returning the chunks found.
+func runWithChunkSize(data string, chunkSize int, newOptions func() []Option) ([]string, error) {
+	return runDataSource(strings.NewReader(data), chunkSize, newOptions)
+}
+
+// runDataSource, same as runWithChunkSize but for any data source.
+func runDataSource(dataSource io.Reader, chunkSize int, newOptions func() []Option) ([]string, error) {
+	chunks := []string{}
+
+	opts := append(newOptions(), WithChunkSize(chunkSize))
+	err := NewProcessor(dataSource, opts...).Run(func(chunk []byte) error {
+		chunks = append(chunks, string(chunk))
+		return nil
+	})
+
+	return chunks, err
+}
+
+// warcRecord, a WARC record with a content of 5 bytes.
+const warcRecord = "WARC/1.0\r\nContent-Length: 5\r\n\r\nhello\r\n\r\n"
+
+// builtInDelimiters, every delimiter of this package with data it delimits into wantChunks chunks.
+var builtInDelimiters = []struct {
+	name       string
+	data       string
+	newOptions func() []Option
+	wantChunks int
+}{
+	{"new line", "a\nbb\n\nccc", withDelimiter(func() DataChunkDelimiter { return DelimiteByNewLine }), 4},
+	{"line", "a\r\nbb\r\nccc", withDelimiter(func() DataChunkDelimiter { return DelimiteByLine }), 3},
+	{
+		"multi-byte separator",
+		"a<|>bb<<|>c<|",
+		withDelimiter(func() DataChunkDelimiter { return DelimiteBySeparator([]byte("<|>")) }),
+		3,
+	},
+	{
+		"any byte",
+		"a b\t\tc ",
+		withDelimiter(func() DataChunkDelimiter { return DelimiteByAnyByte([]byte(" \t"), true) }),
+		3,
+	},
+	{
+		"any delimiter",
+		"a\nb---c\n",
+		withDelimiter(func() DataChunkDelimiter {
+			return DelimiteByAny(DelimiteByNewLine, DelimiteBySeparator([]byte("---")))
+		}),
+		3,
+	},
+	{
+		"padded block",
+		"ab\x00de\x00\x00\x00\x00gh\x00",
+		withDelimiter(func() DataChunkDelimiter { return DelimiteByPaddedBlock(3) }),
+		2,
+	},
+	{"fixed size", "abcdefg", withDelimiter(func() DataChunkDelimiter { return DelimiteByFixedSize(3) }), 3},
+	{
+		"CSV record",
+		"a,\"b\nc\"\nd,\"\"\"e\"\n",
+		withDelimiter(func() DataChunkDelimiter { return DelimiteByCSVRecord('"') }),
+		2,
+	},
+	{
+		"top level indent",
+		"def a():\n  pass\n\ndef b():\n\tpass\n",
+		withDelimiter(func() DataChunkDelimiter { return DelimiteByTopLevelIndent }),
+		2,
+	},
+	{
+		"JSON object",
+		"{\"a\": \"}\"}\n {\n\"b\": {\"c\": 1}\n}",
+		withDelimiter(func() DataChunkDelimiter { return DelimiteByJSONObject }),
+		2,
+	},
+	{
+		"JSON auto",
+		" [{\"a\": 1}, {\"b\": [2]}]",
+		withDelimiter(func() DataChunkDelimiter { return DelimiteByJSONAuto() }),
+		2,
+	},
+	{
+		"WARC record",
+		warcRecord + warcRecord,
+		func() []Option { return []Option{WithDelimiterWithError(DelimiteByWARCRecord)} },
+		2,
+	},
+	{
+		"sub streams",
+		"NL1a\nb\nCSV1c;d",
+		withDelimiter(func() DataChunkDelimiter {
+			return DelimiteBySubStreams(
+				SubStream{Magic: []byte("NL1"), Delimiter: DelimiteByNewLine},
+				SubStream{Magic: []byte("CSV1"), Delimiter: DelimiteBySeparator([]byte(";"))})
+		}),
+		4,
+	},
+	{
+		"length prefix",
+		"\x00\x03abc\x00\x01d",
+		func() []Option {
+			return []Option{WithDelimiterWithError(DelimiteByLengthPrefix(2, binary.BigEndian, 0))}
+		},
+		2,
+	},
+	{
+		"type length value",
+		"\x01\x02ab\x02\x00",
+		func() []Option {
+			return []Option{WithDelimiterWithError(DelimiteByTLV(1, binary.BigEndian, false))}
+		},
+		2,
+	},
+	{
+		"length prefix record",
+		"\x03abc\x00",
+		func() []Option {
+			return []Option{WithRecordDelimiter(DelimiteByLengthPrefixRecord(1, binary.BigEndian, 0))}
+		},
+		2,
+	},
+	{
+		"type length value record",
+		"\x01\x01a\x02\x02bc",
+		func() []Option {
+			return []Option{WithRecordDelimiter(DelimiteByTLVRecord(1, binary.BigEndian))}
+		},
+		2,
+	},
+	{
+		"frame with CRC",
+		crcFrame("abcd", false) + crcFrame("efgh", true) + crcFrame("ijkl", false),
+		func() []Option {
+			return []Option{WithDelimiterWithError(DelimiteByFrameWithCRC(4, CorruptFramePolicySkip, nil))}
+		},
+		2,
+	},
+	{
+		"continued line",
+		"a \\\nb\nc\\",
+		withDelimiterWithEOF(func() DataChunkDelimiterWithEOF { return DelimiteByContinuedLine('\\') }),
+		2,
+	},
+	{
+		"escaped separator",
+		`a\;b;c\;d`,
+		withDelimiterWithEOF(func() DataChunkDelimiterWithEOF { return DelimiteBySeparatorEscaped(';', '\\') }),
+		2,
+	},
+	{
+		"JSON field",
+		"{\"id\":1,\"v\":1}\n{\"id\":1,\"v\":2}\n{\"id\":2,\"v\":3}",
+		withDelimiterWithEOF(func() DataChunkDelimiterWithEOF { return DelimiteByJSONField("id") }),
+		2,
+	},
+	{
+		"mbox",
+		"From a\nhello\n>From me\n\nFrom b\nbye\n",
+		withDelimiterWithEOF(func() DataChunkDelimiterWithEOF { return DelimiteByMbox(true) }),
+		2,
+	},
+	{
+		"paragraph",
+		"first\nparagraph\n\n\nsecond\n",
+		withDelimiterWithEOF(func() DataChunkDelimiterWithEOF { return DelimiteByParagraph }),
+		2,
+	},
+	{
+		"regexp",
+		"a--b---c",
+		withDelimiterWithEOF(func() DataChunkDelimiterWithEOF {
+			return DelimiteByRegexp(regexp.MustCompile("-+"))
+		}),
+		3,
+	},
+	{
+		"telnet",
+		"ab\xff\xfb\x01c\r\nd",
+		withDelimiterWithEOF(func() DataChunkDelimiterWithEOF { return DelimiteByTelnet }),
+		2,
+	},
+}
+
+func TestBuiltInDelimitersByteAtATime(t *testing.T) {
+	for _, tt := range builtInDelimiters {
+		t.Run(tt.name, func(t *testing.T) {
+			want, wantErr := runWithChunkSize(tt.data, largeChunkSize, tt.newOptions)
+
+			if wantErr != nil {
+				t.Fatalf("unexpected error with a large chunk size: %v", wantErr)
+			}
+
+			if len(want) != tt.wantChunks {
+				t.Fatalf("got %q with a large chunk size, want [%d] chunks", want, tt.wantChunks)
+			}
+
+			got, err := runWithChunkSize(tt.data, 1, tt.newOptions)
+
+			if err != nil {
+				t.Fatalf("unexpected error with a chunk size of one: %v", err)
+			}
+
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("got %q with a chunk size of one, want %q as with a large chunk size", got, want)
+			}
+		})
+	}
+}
+
+func TestBuiltInDelimitersNegativeReadCount(t *testing.T) {
+	for _, tt := range builtInDelimiters {
+		for _, chunkSize := range []int{1, 8, largeChunkSize} {
+			t.Run(fmt.Sprintf("%s, chunk size [%d]", tt.name, chunkSize), func(t *testing.T) {
+				defer func() {
+					if r := recover(); r != nil {
+						t.Fatalf("the processing panicked: %v", r)
+					}
+				}()
+
+				dataSource := &negativeCountReader{src: strings.NewReader(tt.data)}
+				_, err := runDataSource(dataSource, chunkSize, tt.newOptions)
+
+				if err == nil || !strings.Contains(err.Error(), "invalid negative read count [-1]") {
+					t.Errorf("got error [%v], want the negative read count", err)
+				}
+			})
+		}
+	}
+}
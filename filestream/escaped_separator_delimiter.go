@@ -0,0 +1,78 @@
+package filestream
+
+// DelimiteBySeparatorEscaped, builds a DataChunkDelimiterWithEOF that splits the data at the first separator byte not
+// preceded by the escape byte, such as ";" in records where a literal one is written as "\;". An escape byte makes the
+// byte after it literal, so an escaped separator never splits the data and an escaped escape byte ("\\") does not
+// escape what follows it. In the chunk emitted the escape bytes in front of a separator or of another escape byte are
+// dropped, any other escape byte is kept as it is. An escape byte at the very end of the data read so far waits for the
+// next read, since the byte it escapes is not known yet, or is kept when the data source ends right after it. The
+// separator itself is dropped, and the data after the last one, unescaped just the same, is the last chunk once the
+// data source ends.
+func DelimiteBySeparatorEscaped(separator byte, escape byte) DataChunkDelimiterWithEOF {
+	return func(chunk []byte, atEOF bool) (bool, []byte, []byte, error) {
+		if len(chunk) == 0 {
+			return false, chunk, nil, nil
+		}
+
+		separatorIndex := -1
+		escapes := 0
+
+		for i := 0; i < len(chunk) && separatorIndex < 0; i++ {
+			switch chunk[i] {
+			case escape:
+				// the escaped byte is not read yet, so there is no telling whether it is a separator.
+				if i+1 == len(chunk) && !atEOF {
+					return false, chunk, nil, nil
+				}
+
+				if i+1 == len(chunk) {
+					break
+				}
+
+				if chunk[i+1] == separator || chunk[i+1] == escape {
+					escapes++
+				}
+
+				i++
+			case separator:
+				separatorIndex = i
+			}
+		}
+
+		if separatorIndex < 0 && !atEOF {
+			return false, chunk, nil, nil
+		}
+
+		// the last record is not terminated, but it is handed over unescaped rather than as it was read.
+		terminated := separatorIndex >= 0
+		leftOver := []byte(nil)
+
+		if !terminated {
+			separatorIndex = len(chunk)
+		} else {
+			leftOver = make([]byte, len(chunk)-separatorIndex-1)
+			copy(leftOver, chunk[separatorIndex+1:])
+		}
+
+		if escapes == 0 {
+			return terminated, chunk[:separatorIndex], leftOver, nil
+		}
+
+		return terminated, unescape(chunk[:separatorIndex], separator, escape, escapes), leftOver, nil
+	}
+}
+
+// unescape, returns a copy of the record without the escape bytes in front of a separator or of another escape byte.
+func unescape(record []byte, separator byte, escape byte, escapes int) []byte {
+	unescaped := make([]byte, 0, len(record)-escapes)
+
+	for i := 0; i < len(record); i++ {
+		if record[i] == escape && i+1 < len(record) && (record[i+1] == separator || record[i+1] == escape) {
+			i++
+		}
+
+		unescaped = append(unescaped, record[i])
+	}
+
+	return unescaped
+}
@@ -0,0 +1,40 @@
+package filestream
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDelimiteBySeparatorEscaped(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []string
+	}{
+		{"records", `a;b;`, []string{`a`, `b`}},
+		{"escaped separator", `a\;b;c;`, []string{`a;b`, `c`}},
+		{"escaped escape", `a\\;b;`, []string{`a\`, `b`}},
+		{"other escapes kept", `a\nb;`, []string{`a\nb`}},
+		{"last record without separator", `a;b\;c`, []string{`a`, `b;c`}},
+		{"escaped escape in the last record", `a;b\\c`, []string{`a`, `b\c`}},
+		{"escape at the end", `a;b\`, []string{`a`, `b\`}},
+		{"empty records", ";;", []string{"", ""}},
+		{"empty", "", []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := collectChunks(t, tt.data, withDelimiterWithEOF(func() DataChunkDelimiterWithEOF {
+				return DelimiteBySeparatorEscaped(';', '\\')
+			}))
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
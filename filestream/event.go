@@ -0,0 +1,25 @@
+package filestream
+
+import "time"
+
+// Event, a chunk wrapped with a sequence number, starting at one and incremented for every chunk, and the moment
+// it was received from the delimiter.
+type Event struct {
+	Seq        int
+	ReceivedAt time.Time
+	Data       []byte
+}
+
+// WrapInEvents, builds a DataChunkHandler that wraps every chunk in a Event before handing it to the eventHandler,
+// meant for event processing pipelines. The timestamps carry the monotonic clock reading, so they never go backwards
+// between events.
+// NOTE: just as the chunk, the Data of an event is only valid until the eventHandler returns.
+func WrapInEvents(eventHandler func(Event) error) DataChunkHandler {
+	seq := 0
+
+	return func(b []byte) error {
+		seq++
+
+		return eventHandler(Event{Seq: seq, ReceivedAt: time.Now(), Data: b})
+	}
+}
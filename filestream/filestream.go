@@ -0,0 +1,207 @@
+// Package filestream, processes data sources such as files, zip archives or any other io.Reader in small chunks,
+// allowing large amounts of data to be handled without loading the whole data into memory.
+package filestream
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+)
+
+type (
+	// DataChunkHandler, function that will handle the data as soon as it is determinated by the DataChunkDelimiter
+	// function, an error returned by it stops the processing, which returns it wrapped in a ChunkError.
+	// NOTE: the memory of the chunk is reused once the handler returns, so the handler must not retain it, it must be
+	// copied to be kept any longer.
+	// NOTE: the processing keeps no state outside of each call, so a handler may process its chunk as a data source of
+	// its own, such as a decoded blob holding records of another format, by calling ProcessBytes, or any other
+	// processing function, on it with its own delimiter before returning.
+	DataChunkHandler func([]byte) error
+
+	// DataChunkDelimiter, function that determinates the size of the chunk that is going to be processed, it receives a
+	// byte array and should return "false", the original "byte array" paramenter and "nil" in case the chunk is not
+	// enought or return "true" following by the chunk to be processed and the left over bytes that should not be
+	// processed at least for now.
+	// NOTE: the boolean returned is in case that the byte array that was send is enough to be processed and there is no
+	// left overs to return.
+	// NOTE: while more data is needed the delimiter may drop bytes it already knows not to be data from the beginning of
+	// the byte array it gives back, such as a run of separators, the rest of it must be given back as it was received.
+	// NOTE: the chunk returned is handed to the DataChunkHandler as it is, so removing the bytes that delimited it, such
+	// as a new line, is up to the delimiter.
+	DataChunkDelimiter func([]byte) (bool, []byte, []byte)
+
+	// DataChunkDelimiterWithError, same as DataChunkDelimiter but it can also find the data to be invalid, such as a
+	// frame declaring an impossible length, and return an error, which stops the processing right away.
+	DataChunkDelimiterWithError func([]byte) (bool, []byte, []byte, error)
+
+	// DataChunkDelimiterWithLookahead, same as DataChunkDelimiterWithError but whenever more data is needed it also
+	// tells how many bytes at least must follow the data it gives back before it can decide, such as the rest of a
+	// record whose length is already known, so the data is read without calling it again until that many bytes arrive
+	// or the data source is over, instead of scanning the same data again after every read. Zero, the answer of any
+	// other delimiter, means it is called again after the next read.
+	DataChunkDelimiterWithLookahead func([]byte) (bool, []byte, []byte, int, error)
+
+	// DataChunkDelimiterWithEOF, same as DataChunkDelimiterWithError but it is also told whether the data source is
+	// over. Once it is, the delimiter is called one last time with all the data left and "true", so it can turn that
+	// data into the last chunk itself, such as unescaping it, instead of having it handed over exactly as it was read.
+	// Returning "true" at that point delimits a chunk, calling it again with the left over, while returning "false"
+	// hands what it gives back over as data that was never delimited, which WithStrictTermination turns into an error.
+	// Nothing at all means there is no last chunk.
+	DataChunkDelimiterWithEOF func([]byte, bool) (bool, []byte, []byte, error)
+)
+
+// ErrStopProcessing, returned by a DataChunkHandler to stop the processing once it got what it needed, such as the
+// first matching record, the rest of the data source is not read and the processing returns nil, since nothing failed.
+var ErrStopProcessing = errors.New("stop processing")
+
+// ErrTrailingData, returned by a Processor built with WithStrictTermination for a data source with data left after its
+// last delimited chunk.
+var ErrTrailingData = errors.New("trailing data")
+
+// ErrNoProgress, returned whenever a DataChunkDelimiter finds an empty chunk while leaving all the data it was given
+// over, which would make it find the same empty chunk forever.
+var ErrNoProgress = errors.New("delimiter made no progress")
+
+const (
+	newLineByte = byte('\n')
+
+	// defaultChunkSize, the size of the chunk fetched from the data source by the helpers that do not take one.
+	defaultChunkSize = 128
+)
+
+// ProcessDataSourceInChunks, it is a function that will split a byte array in chunks of data to process each part at a
+// time allowing large files to be processed in small parts avoiding large ammounts of memory to be allocation. This
+// method is primarily focused on dealing with files containing JSON data splited in lines.
+// NOTE: the chunk size only bounds how many bytes are asked for in each read, the chunks delimited are the same for any
+// chunk size, even one, which reads the data source byte by byte and assembles multi-byte separators one read at a
+// time. A chunk size smaller than one is handled as one, silently, only a Processor built with WithLogger warns about
+// it, and the line delimiters, which are scanned by a bufio.Reader, read at least 16 bytes at a time.
+// NOTE: every delimiter found ends a chunk, even when there is no data before it, so "\n" is a single empty chunk and
+// "\n\n" two of them, while the data after the last delimiter is only a chunk when there is any, so an empty data
+// source has no chunk at all. WithSkipEmpty drops the empty chunks instead.
+func ProcessDataSourceInChunks(
+	dataSource io.Reader,
+	chunkSize int,
+	chunkHandler DataChunkHandler,
+	chunkDelimiter DataChunkDelimiter) error {
+	return NewProcessor(dataSource, WithChunkSize(chunkSize), WithDelimiter(chunkDelimiter)).Run(chunkHandler)
+}
+
+// ProcessDataSourceInChunksContext, same as ProcessDataSourceInChunks but the processing is aborted as soon as the
+// context is cancelled or its deadline passes, returning the error of the context. The context is checked before every
+// chunk is handled and between reads, so no chunk is handed to the handler once it is done. A read blocked in a data
+// source supporting read deadlines, such as a net.Conn, is interrupted as soon as the context is done.
+// NOTE: a read blocked in any other data source can not be interrupted, the context is only noticed once it returns.
+func ProcessDataSourceInChunksContext(
+	ctx context.Context,
+	dataSource io.Reader,
+	chunkSize int,
+	chunkHandler DataChunkHandler,
+	chunkDelimiter DataChunkDelimiter) error {
+	processor := NewProcessor(
+		dataSource,
+		WithContext(ctx),
+		WithChunkSize(chunkSize),
+		WithDelimiter(chunkDelimiter))
+
+	return processor.Run(chunkHandler)
+}
+
+// ProcessDataSourceInChunksBounded, same as ProcessDataSourceInChunks but the data collected while looking for the end
+// of a chunk is not allowed to grow beyond maxChunkSize bytes, the processing stops with an ErrChunkTooLarge instead,
+// so a data source that never contains the delimiter, such as a huge file with no new line at all, does not exhaust
+// the memory. A maxChunkSize of zero means there is no limit, just as in ProcessDataSourceInChunks.
+func ProcessDataSourceInChunksBounded(
+	dataSource io.Reader,
+	chunkSize int,
+	maxChunkSize int,
+	chunkHandler DataChunkHandler,
+	chunkDelimiter DataChunkDelimiter) error {
+	processor := NewProcessor(
+		dataSource,
+		WithChunkSize(chunkSize),
+		WithMaxChunkSize(maxChunkSize),
+		WithDelimiter(chunkDelimiter))
+
+	return processor.Run(chunkHandler)
+}
+
+// processDataSourceInChunksUntil, same as ProcessDataSourceInChunksContext but whenever the stop function is given and it
+// returns "true" for a chunk, that chunk is not handled and the processing stops right away, returning the left over
+// bytes already read from the data source but not processed yet, so the caller can go on reading from where the
+// processing stopped.
+func processDataSourceInChunksUntil(
+	ctx context.Context,
+	dataSource io.Reader,
+	chunkSize int,
+	maxChunkSize int,
+	chunkHandler DataChunkHandler,
+	chunkDelimiter DataChunkDelimiter,
+	stop func([]byte) bool) ([]byte, error) {
+	scanner := newChunkScanner(ctx, dataSource, chunkSize, maxChunkSize, chunkDelimiter)
+
+	return processChunks(scanner, chunkHandler, stop)
+}
+
+// processChunks, hands every chunk found by the scanner to the handler, it stops as processDataSourceInChunksUntil does.
+func processChunks(scanner *ChunkScanner, chunkHandler DataChunkHandler, stop func([]byte) bool) ([]byte, error) {
+	for scanner.Scan() {
+		chunkToBeProcessed := scanner.Bytes()
+
+		if stop != nil && stop(chunkToBeProcessed) {
+			return scanner.remaining(), nil
+		}
+
+		err := chunkHandler(chunkToBeProcessed)
+
+		if errors.Is(err, ErrStopProcessing) {
+			return scanner.remaining(), nil
+		}
+
+		if err != nil {
+			return nil, &ChunkError{ChunkMeta: ChunkMeta{Offset: scanner.chunkOffset, Index: scanner.chunkIndex}, Err: err}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return scanner.remaining(), nil
+}
+
+// removeTrailingNewLine, removes a single new line from the end of the data, if there is one, new lines anywhere else
+// are data and are kept.
+func removeTrailingNewLine(b []byte) []byte {
+	if len(b) > 0 && b[len(b)-1] == newLineByte {
+		return b[:len(b)-1]
+	}
+
+	return b
+}
+
+// DelimiteByNewLine, one implementaiton of DataChunkDelimiter, this function will receive a byte array as parameter and
+// will try to determinete whether or not this chunk of data is enough to be processed by checking by a new line "\n"
+// character at any point of the array, all data before the new line will be considered an complete chunk, part after
+// the new line will be considered as left overs.
+// NOTE: whenever DelimiteByNewLine or DelimiteByLine is handed to the engine itself, the new lines are looked for by a
+// bufio.Reader as the data source is read instead, which scans every byte only once, no matter how long the lines are.
+func DelimiteByNewLine(chunk []byte) (bool, []byte, []byte) {
+	// only the first new line matters, all data before it is the chunk and all data after it is the left over, which
+	// is going to be delimited again, so there is no point in looking for the other new lines now.
+	// NOTE: neither the chunk nor the left over are copied, both are sub slices of the data given, the engine moves the
+	// left over to the beginning of its memory before reading again, which copy handles even though they overlap, and
+	// the chunk can not grow past the new line, so a handler appending to it gets memory of its own instead of writing
+	// over the left over.
+	lineEnd := bytes.IndexByte(chunk, newLineByte)
+
+	if lineEnd < 0 {
+		return false, chunk, nil
+	}
+
+	// empty lines are blank lines and are kept as empty chunks, the left over must match exactly the data given.
+	leftOverStart := lineEnd + 1
+
+	return true, chunk[:lineEnd:leftOverStart], chunk[leftOverStart:]
+}
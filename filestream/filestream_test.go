@@ -0,0 +1,369 @@
+package filestream
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// chunkSizes, the chunk sizes the delimiters are tested with, byte at a time reads included, since the chunks found
+// must be the same for any of them.
+var chunkSizes = []int{1, 2, 3, 7, 128}
+
+// collectChunks, processes the data once for every one of chunkSizes, with a Processor built by the options newOptions
+// returns, built again for every run since some delimiters hold state, and returns the chunks found, failing the test
+// whenever the chunk size changes them.
+func collectChunks(t *testing.T, data string, newOptions func() []Option) ([]string, error) {
+	t.Helper()
+
+	var first []string
+	var firstErr error
+
+	for i, chunkSize := range chunkSizes {
+		chunks := []string{}
+		chunkHandler := func(chunk []byte) error {
+			chunks = append(chunks, string(chunk))
+			return nil
+		}
+
+		opts := append(newOptions(), WithChunkSize(chunkSize))
+		err := NewProcessor(strings.NewReader(data), opts...).Run(chunkHandler)
+
+		if i == 0 {
+			first, firstErr = chunks, err
+			continue
+		}
+
+		if !reflect.DeepEqual(chunks, first) || (err == nil) != (firstErr == nil) {
+			t.Fatalf(
+				"chunk size [%d] found %q (%v), chunk size [%d] found %q (%v)",
+				chunkSize,
+				chunks,
+				err,
+				chunkSizes[0],
+				first,
+				firstErr)
+		}
+	}
+
+	return first, firstErr
+}
+
+// withDelimiter, the options of a Processor delimiting by the DataChunkDelimiter newDelimiter builds.
+func withDelimiter(newDelimiter func() DataChunkDelimiter) func() []Option {
+	return func() []Option {
+		return []Option{WithDelimiter(newDelimiter())}
+	}
+}
+
+// withDelimiterWithEOF, the options of a Processor delimiting by the DataChunkDelimiterWithEOF newDelimiter builds.
+func withDelimiterWithEOF(newDelimiter func() DataChunkDelimiterWithEOF) func() []Option {
+	return func() []Option {
+		return []Option{WithDelimiterWithEOF(newDelimiter())}
+	}
+}
+
+// messagesLogger, a Logger keeping every message written to it.
+type messagesLogger struct {
+	messages []string
+}
+
+func (l *messagesLogger) Printf(format string, args ...interface{}) {
+	l.messages = append(l.messages, fmt.Sprintf(format, args...))
+}
+
+func TestInvalidChunkSize(t *testing.T) {
+	var standardLog bytes.Buffer
+
+	log.SetOutput(&standardLog)
+	defer log.SetOutput(os.Stderr)
+
+	want := []string{"first", "second", "third"}
+
+	for _, chunkSize := range []int{0, -1} {
+		wantWarning := fmt.Sprintf("invalid chunk size [%d]", chunkSize)
+
+		t.Run(fmt.Sprintf("ProcessDataSourceInChunks, chunk size [%d]", chunkSize), func(t *testing.T) {
+			standardLog.Reset()
+			var got []string
+
+			err := ProcessDataSourceInChunks(
+				strings.NewReader("first\nsecond\nthird"),
+				chunkSize,
+				func(chunk []byte) error {
+					got = append(got, string(chunk))
+					return nil
+				},
+				DelimiteByNewLine)
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("got %q, want %q", got, want)
+			}
+
+			if standardLog.Len() > 0 {
+				t.Errorf("the standard log holds %q, want nothing without a logger", standardLog.String())
+			}
+		})
+
+		t.Run(fmt.Sprintf("Processor without a logger, chunk size [%d]", chunkSize), func(t *testing.T) {
+			standardLog.Reset()
+			var got []string
+
+			p := NewProcessor(strings.NewReader("first\nsecond\nthird"), WithChunkSize(chunkSize))
+
+			err := p.Run(func(chunk []byte) error {
+				got = append(got, string(chunk))
+				return nil
+			})
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("got %q, want %q", got, want)
+			}
+
+			if standardLog.Len() > 0 {
+				t.Errorf("the standard log holds %q, want nothing without a logger", standardLog.String())
+			}
+		})
+
+		t.Run(fmt.Sprintf("WithLogger, chunk size [%d]", chunkSize), func(t *testing.T) {
+			standardLog.Reset()
+			logger := &messagesLogger{}
+			var got []string
+
+			p := NewProcessor(
+				strings.NewReader("first,second,third"),
+				WithChunkSize(chunkSize),
+				WithDelimiter(DelimiteBySeparator([]byte(","))),
+				WithLogger(logger))
+
+			err := p.Run(func(chunk []byte) error {
+				got = append(got, string(chunk))
+				return nil
+			})
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("got %q, want %q", got, want)
+			}
+
+			if len(logger.messages) != 1 || !strings.Contains(logger.messages[0], wantWarning) {
+				t.Errorf("the logger got %q, want the warning %q", logger.messages, wantWarning)
+			}
+
+			if standardLog.Len() > 0 {
+				t.Errorf("the standard log holds %q, want nothing", standardLog.String())
+			}
+		})
+	}
+}
+
+func TestTrailingNewLine(t *testing.T) {
+	delimiters := []struct {
+		name           string
+		chunkDelimiter DataChunkDelimiter
+	}{
+		{"new line", DelimiteByNewLine},
+		{"line", DelimiteByLine},
+		{"separator", DelimiteBySeparator([]byte("\n"))},
+	}
+
+	tests := []struct {
+		name string
+		data string
+		want []string
+	}{
+		{"with trailing new line", "{\"a\":1}\n{\"b\":2}\n", []string{`{"a":1}`, `{"b":2}`}},
+		{"without trailing new line", "{\"a\":1}\n{\"b\":2}", []string{`{"a":1}`, `{"b":2}`}},
+		{"blank last line", "{\"a\":1}\n\n", []string{`{"a":1}`, ""}},
+		{"single line with trailing new line", "{\"a\":1}\n", []string{`{"a":1}`}},
+		{"single line without trailing new line", `{"a":1}`, []string{`{"a":1}`}},
+		{"new line only", "\n", []string{""}},
+		{"empty", "", []string{}},
+	}
+
+	for _, delimiter := range delimiters {
+		for _, tt := range tests {
+			t.Run(delimiter.name+", "+tt.name, func(t *testing.T) {
+				got, err := collectChunks(t, tt.data, withDelimiter(func() DataChunkDelimiter {
+					return delimiter.chunkDelimiter
+				}))
+
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("got %q, want %q", got, tt.want)
+				}
+			})
+		}
+	}
+}
+
+// splitFirstLine, the former implementation of DelimiteByNewLine, which copied the whole chunk and split all of it to
+// keep the first line only.
+func splitFirstLine(chunk []byte) (bool, []byte, []byte) {
+	chunkCopy := make([]byte, len(chunk))
+	copy(chunkCopy, chunk)
+
+	parts := bytes.Split(chunkCopy, []byte{newLineByte})
+
+	if len(parts) == 1 {
+		return false, chunk, nil
+	}
+
+	return true, parts[0], bytes.Join(parts[1:], []byte{newLineByte})
+}
+
+func TestDelimiteByNewLine(t *testing.T) {
+	tests := []struct {
+		name  string
+		chunk string
+	}{
+		{"lines", "first\nsecond\nthird"},
+		{"terminated line", "first\n"},
+		{"blank lines", "\n\nthird\n"},
+		{"no new line", "first"},
+		{"empty", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := []byte(tt.chunk)
+			enough, chunk, leftOver := DelimiteByNewLine(data)
+			wantEnough, wantChunk, wantLeftOver := splitFirstLine([]byte(tt.chunk))
+
+			if enough != wantEnough || !bytes.Equal(chunk, wantChunk) || !bytes.Equal(leftOver, wantLeftOver) {
+				t.Errorf(
+					"got (%v, %q, %q), want (%v, %q, %q)",
+					enough,
+					chunk,
+					leftOver,
+					wantEnough,
+					wantChunk,
+					wantLeftOver)
+			}
+
+			// the left over shares the memory of the data delimited, so a handler appending to the chunk must not
+			// write over it.
+			if enough {
+				wantLeftOver := string(leftOver)
+				_ = append(chunk, "##"...)
+
+				if string(leftOver) != wantLeftOver {
+					t.Errorf("appending to the chunk changed the left over to %q, want %q", leftOver, wantLeftOver)
+				}
+			}
+		})
+	}
+}
+
+// shortLines, a data source with many short lines, where the cost of delimiting dominates the processing.
+var shortLines = []byte(strings.Repeat(`{"id":1234,"ok":true}`+"\n", 4096))
+
+func BenchmarkDelimiteByNewLine(b *testing.B) {
+	delimiters := []struct {
+		name           string
+		chunkDelimiter DataChunkDelimiter
+	}{
+		{"index byte", DelimiteByNewLine},
+		{"split", splitFirstLine},
+	}
+
+	// the lines are delimited out of a single read of 4 KiB, over and over, as long as a new line is found.
+	read := shortLines[:4096]
+
+	for _, delimiter := range delimiters {
+		b.Run(delimiter.name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(read)))
+
+			for i := 0; i < b.N; i++ {
+				data := read
+
+				for {
+					enough, _, leftOver := delimiter.chunkDelimiter(data)
+
+					if !enough {
+						break
+					}
+
+					data = leftOver
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkProcessNewLines(b *testing.B) {
+	options := []struct {
+		name string
+		opts []Option
+	}{
+		{"line scanning", []Option{WithDelimiter(DelimiteByNewLine)}},
+		{"delimiter calls", []Option{WithDelimiter(DelimiteByNewLine), WithDelimiterTiming(func(time.Duration) {})}},
+	}
+
+	for _, option := range options {
+		b.Run(option.name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(shortLines)))
+
+			for i := 0; i < b.N; i++ {
+				opts := append([]Option{WithChunkSize(4096)}, option.opts...)
+				err := NewProcessor(bytes.NewReader(shortLines), opts...).Run(func([]byte) error { return nil })
+
+				if err != nil {
+					b.Fatalf("unexpected error: %v", err)
+				}
+			}
+		})
+	}
+}
+
+// longLines, a data source with a few lines of 1 MiB, each one read in hundreds of reads before its new line is found.
+var longLines = []byte(strings.Repeat(strings.Repeat("x", 1<<20)+"\n", 4))
+
+func BenchmarkProcessLongLines(b *testing.B) {
+	options := []struct {
+		name string
+		opts []Option
+	}{
+		// the bufio.Reader looks at every byte read once, no matter how many reads a line takes.
+		{"line scanning", []Option{WithDelimiter(DelimiteByNewLine)}},
+		// the delimiter looks for the new line again in all the data collected after every read, which is quadratic.
+		{"delimiter calls", []Option{WithDelimiter(DelimiteByNewLine), WithDelimiterTiming(func(time.Duration) {})}},
+	}
+
+	for _, option := range options {
+		b.Run(option.name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(longLines)))
+
+			for i := 0; i < b.N; i++ {
+				opts := append([]Option{WithChunkSize(4096)}, option.opts...)
+				err := NewProcessor(bytes.NewReader(longLines), opts...).Run(func([]byte) error { return nil })
+
+				if err != nil {
+					b.Fatalf("unexpected error: %v", err)
+				}
+			}
+		})
+	}
+}
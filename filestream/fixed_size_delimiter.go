@@ -0,0 +1,41 @@
+package filestream
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrShortRecord, returned by the handlers built by RejectShortRecords for a record shorter than its fixed size, and by
+// the ones built by HandleTLVRecords for a record shorter than its header.
+var ErrShortRecord = errors.New("short record")
+
+// DelimiteByFixedSize, builds a DataChunkDelimiter for binary protocols where every record is exactly n bytes long with
+// no separator between them, the first n bytes collected are the chunk and the rest is the left over. Whatever is left
+// when the data source ends, less than n bytes, is handed over as a short last record, RejectShortRecords turns it into
+// an error instead.
+// NOTE: just as any other data never delimited, the short last record is handed over without its trailing new line
+// byte, if it ends with one.
+func DelimiteByFixedSize(n int) DataChunkDelimiter {
+	return func(chunk []byte) (bool, []byte, []byte) {
+		if n <= 0 || len(chunk) < n {
+			return false, chunk, nil
+		}
+
+		leftOver := make([]byte, len(chunk)-n)
+		copy(leftOver, chunk[n:])
+
+		return true, chunk[:n], leftOver
+	}
+}
+
+// RejectShortRecords, wraps a DataChunkHandler so a record shorter than n bytes, such as the short last record of a
+// data source delimited by DelimiteByFixedSize, stops the processing with an ErrShortRecord instead of being handled.
+func RejectShortRecords(n int, chunkHandler DataChunkHandler) DataChunkHandler {
+	return func(b []byte) error {
+		if len(b) < n {
+			return fmt.Errorf("%w: [%d] bytes, records have [%d] bytes", ErrShortRecord, len(b), n)
+		}
+
+		return chunkHandler(b)
+	}
+}
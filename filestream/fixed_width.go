@@ -0,0 +1,51 @@
+package filestream
+
+import (
+	"fmt"
+	"io"
+)
+
+// FieldSpec, a named field of a fixed width record, the fields of a record are laid out one after the other in the
+// order of the layout, each one taking exactly Width bytes.
+type FieldSpec struct {
+	Name  string
+	Width int
+}
+
+// ProcessFixedWidth, processes a data source of fixed width records, one per line, with either "\n" or "\r\n" as
+// terminator, slicing every record into the fields of the layout and handing them to the handler by name. The values
+// are kept as they are in the record, padding included. Bytes past the last field of the layout are ignored, while a
+// record too short to hold all the fields stops the processing with an error.
+func ProcessFixedWidth(r io.Reader, layout []FieldSpec, handler func(fields map[string][]byte) error) error {
+	recordWidth := 0
+
+	for _, field := range layout {
+		recordWidth += field.Width
+	}
+
+	record := 0
+
+	chunkHandler := func(b []byte) error {
+		record++
+
+		if len(b) < recordWidth {
+			return fmt.Errorf(
+				"fixed width record [%d] has [%d] bytes, the layout needs [%d] bytes",
+				record,
+				len(b),
+				recordWidth)
+		}
+
+		fields := make(map[string][]byte, len(layout))
+		start := 0
+
+		for _, field := range layout {
+			fields[field.Name] = b[start : start+field.Width]
+			start += field.Width
+		}
+
+		return handler(fields)
+	}
+
+	return ProcessDataSourceInChunks(r, defaultChunkSize, chunkHandler, DelimiteByLine)
+}
@@ -0,0 +1,69 @@
+package filestream
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+)
+
+const frameCRCSize = 4
+
+// ErrCorruptFrame, returned by the delimiters built by DelimiteByFrameWithCRC with CorruptFramePolicyReject for a frame
+// whose payload does not match its CRC.
+var ErrCorruptFrame = errors.New("corrupt frame")
+
+// CorruptFramePolicy, determinates what DelimiteByFrameWithCRC does with the frames failing their CRC verification.
+type CorruptFramePolicy int
+
+const (
+	// CorruptFramePolicySkip, corrupt frames are dropped and processing goes on with the next frame.
+	CorruptFramePolicySkip CorruptFramePolicy = iota
+	// CorruptFramePolicyReject, processing stops with ErrCorruptFrame at the first corrupt frame.
+	CorruptFramePolicyReject
+)
+
+// DelimiteByFrameWithCRC, builds a DataChunkDelimiterWithError for binary frames made of a fixed size payload followed
+// by the CRC-32 (IEEE) of that payload written as 4 big endian bytes, each frame is verified and only its payload is
+// emitted. A frame failing the verification is handed to onCorruptFrame, when it is given, as soon as it is complete,
+// and then the CorruptFramePolicy decides whether it is dropped or stops the processing with an ErrCorruptFrame.
+func DelimiteByFrameWithCRC(
+	payloadLen int,
+	policy CorruptFramePolicy,
+	onCorruptFrame func([]byte)) DataChunkDelimiterWithError {
+	frameLen := payloadLen + frameCRCSize
+
+	return func(chunk []byte) (bool, []byte, []byte, error) {
+		start := 0
+
+		for ; start+frameLen <= len(chunk); start += frameLen {
+			frame := chunk[start : start+frameLen]
+			payload := frame[:payloadLen]
+			expectedCRC := binary.BigEndian.Uint32(frame[payloadLen:])
+
+			if actualCRC := crc32.ChecksumIEEE(payload); actualCRC != expectedCRC {
+				if onCorruptFrame != nil {
+					onCorruptFrame(frame)
+				}
+
+				if policy == CorruptFramePolicyReject {
+					return false, chunk, nil, fmt.Errorf(
+						"%w: the payload CRC is [%08x] but the frame declares [%08x]",
+						ErrCorruptFrame,
+						actualCRC,
+						expectedCRC)
+				}
+
+				continue
+			}
+
+			leftOver := make([]byte, len(chunk)-start-frameLen)
+			copy(leftOver, chunk[start+frameLen:])
+
+			return true, payload, leftOver, nil
+		}
+
+		// the corrupt frames checked are dropped right away, so they are neither checked again nor handed over.
+		return false, chunk[start:], nil, nil
+	}
+}
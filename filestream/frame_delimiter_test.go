@@ -0,0 +1,93 @@
+package filestream
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"reflect"
+	"testing"
+)
+
+// crcFrame, a frame of DelimiteByFrameWithCRC for the payload, its CRC is corrupted when corrupt is set.
+func crcFrame(payload string, corrupt bool) string {
+	crc := crc32.ChecksumIEEE([]byte(payload))
+
+	if corrupt {
+		crc++
+	}
+
+	frame := make([]byte, frameCRCSize)
+	binary.BigEndian.PutUint32(frame, crc)
+
+	return payload + string(frame)
+}
+
+func TestDelimiteByFrameWithCRC(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        string
+		policy      CorruptFramePolicy
+		want        []string
+		wantCorrupt []string
+		wantErr     error
+	}{
+		{
+			name: "valid frames",
+			data: crcFrame("abc", false) + crcFrame("def", false),
+			want: []string{"abc", "def"},
+		},
+		{
+			name:        "corrupt frame skipped",
+			data:        crcFrame("abc", false) + crcFrame("bad", true) + crcFrame("def", false),
+			want:        []string{"abc", "def"},
+			wantCorrupt: []string{crcFrame("bad", true)},
+		},
+		{
+			name:        "trailing corrupt frame skipped",
+			data:        crcFrame("abc", false) + crcFrame("bad", true),
+			want:        []string{"abc"},
+			wantCorrupt: []string{crcFrame("bad", true)},
+		},
+		{
+			name:        "corrupt frames in a row",
+			data:        crcFrame("ba1", true) + crcFrame("ba2", true) + crcFrame("def", false),
+			want:        []string{"def"},
+			wantCorrupt: []string{crcFrame("ba1", true), crcFrame("ba2", true)},
+		},
+		{
+			name:        "corrupt frame rejected",
+			data:        crcFrame("abc", false) + crcFrame("bad", true) + crcFrame("def", false),
+			policy:      CorruptFramePolicyReject,
+			want:        []string{"abc"},
+			wantCorrupt: []string{crcFrame("bad", true)},
+			wantErr:     ErrCorruptFrame,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var corrupt []string
+
+			got, err := collectChunks(t, tt.data, func() []Option {
+				corrupt = nil
+				onCorruptFrame := func(frame []byte) {
+					corrupt = append(corrupt, string(frame))
+				}
+
+				return []Option{WithDelimiterWithError(DelimiteByFrameWithCRC(3, tt.policy, onCorruptFrame))}
+			})
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+
+			if !reflect.DeepEqual(corrupt, tt.wantCorrupt) {
+				t.Errorf("got corrupt frames %q, want %q", corrupt, tt.wantCorrupt)
+			}
+		})
+	}
+}
@@ -0,0 +1,322 @@
+package filestream
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"strings"
+	"time"
+)
+
+// ErrChunkTooLarge, returned whenever a chunk, or the data collected while looking for the end of one, is larger than
+// the limit given.
+var ErrChunkTooLarge = errors.New("chunk too large")
+
+// CountMatching, processes the whole data source with the given DataChunkDelimiter and, instead of a full
+// DataChunkHandler, only evaluates the predicate against each chunk, returning how many chunks matched it and how many
+// chunks were found in total.
+func CountMatching(
+	dataSource io.Reader,
+	predicate func([]byte) bool,
+	chunkDelimiter DataChunkDelimiter) (int, int, error) {
+	matched := 0
+	total := 0
+
+	chunkHandler := func(b []byte) error {
+		total++
+
+		if predicate(b) {
+			matched++
+		}
+
+		return nil
+	}
+
+	err := ProcessDataSourceInChunks(dataSource, defaultChunkSize, chunkHandler, chunkDelimiter)
+
+	return matched, total, err
+}
+
+// RouteControlChunks, builds a DataChunkHandler for streams that interleave data records with out of band control
+// frames delimited the same way, every chunk is classified and control frames are routed to the controlHandler while
+// all the others are routed to the dataHandler.
+func RouteControlChunks(
+	isControl func([]byte) bool,
+	controlHandler DataChunkHandler,
+	dataHandler DataChunkHandler) DataChunkHandler {
+	return func(b []byte) error {
+		if isControl(b) {
+			return controlHandler(b)
+		}
+
+		return dataHandler(b)
+	}
+}
+
+// ProcessString, processes an in memory string in chunks just like ProcessDataSourceInChunks does with any other data
+// source, it is mostly handy to test DataChunkHandler and DataChunkDelimiter functions, where a small chunk size makes
+// chunks span many reads. An empty string never calls the handler.
+func ProcessString(s string, chunkSize int, chunkHandler DataChunkHandler, chunkDelimiter DataChunkDelimiter) error {
+	return ProcessDataSourceInChunks(strings.NewReader(s), chunkSize, chunkHandler, chunkDelimiter)
+}
+
+// ProcessBytes, same as ProcessString but for an in memory byte array.
+func ProcessBytes(b []byte, chunkSize int, chunkHandler DataChunkHandler, chunkDelimiter DataChunkDelimiter) error {
+	return ProcessDataSourceInChunks(bytes.NewReader(b), chunkSize, chunkHandler, chunkDelimiter)
+}
+
+// HookBefore, wraps a DataChunkHandler calling the before hook with the index of the chunk, starting at zero, and
+// the chunk itself ahead of the handler. The hook can veto the chunk by returning "true", dropping it without calling
+// the handler, or abort the whole processing by returning an error.
+func HookBefore(before func(int, []byte) (bool, error), chunkHandler DataChunkHandler) DataChunkHandler {
+	index := 0
+
+	return func(b []byte) error {
+		skip, err := before(index, b)
+		index++
+
+		if err != nil {
+			return err
+		}
+
+		if skip {
+			return nil
+		}
+
+		return chunkHandler(b)
+	}
+}
+
+// SkipChunks, wraps a DataChunkHandler dropping the first n chunks without handling them, it allows resuming sources
+// that can not be seeked, such as an entry of a zip archive, by decompressing and delimiting them again from the start
+// while the records already processed before a crash are skipped at the cost of the delimiting only.
+func SkipChunks(n int, chunkHandler DataChunkHandler) DataChunkHandler {
+	skipped := 0
+
+	return func(b []byte) error {
+		if skipped < n {
+			skipped++
+			return nil
+		}
+
+		return chunkHandler(b)
+	}
+}
+
+// SkipLeadingChunks, wraps a DataChunkHandler dropping the chunks found at the very beginning of the data source for
+// which the predicate returns "true", such as a "#!" shebang or "# comment" preamble lines, once a chunk does not match
+// it every chunk goes to the handler, matching or not.
+func SkipLeadingChunks(predicate func([]byte) bool, chunkHandler DataChunkHandler) DataChunkHandler {
+	skipping := true
+
+	return func(b []byte) error {
+		if skipping && predicate(b) {
+			return nil
+		}
+
+		skipping = false
+
+		return chunkHandler(b)
+	}
+}
+
+// HashChunks, builds a DataChunkHandler that computes the 64 bits FNV-1a hash of every chunk and hands it to the
+// hashedChunkHandler along with the chunk, so identical records, having identical hashes, can be deduplicated
+// downstream without keeping them all in memory.
+// NOTE: FNV-1a is fast but not cryptographic, different chunks may collide, so a match should be confirmed by comparing
+// the chunks themselves whenever a false positive matters.
+func HashChunks(hashedChunkHandler func(chunk []byte, hash uint64) error) DataChunkHandler {
+	return func(b []byte) error {
+		h := fnv.New64a()
+		h.Write(b)
+
+		return hashedChunkHandler(b, h.Sum64())
+	}
+}
+
+// SplitOversizedChunks, builds a DataChunkHandler that sub divides every chunk larger than maxSize bytes in pieces of
+// at most maxSize bytes before handing them to the continuedChunkHandler, one call per piece, being the continuation
+// flag "false" for the first piece of a chunk and "true" for all the pieces that follow it. A maxSize of zero or less
+// does not split anything.
+func SplitOversizedChunks(maxSize int, continuedChunkHandler func([]byte, bool) error) DataChunkHandler {
+	return func(b []byte) error {
+		if maxSize <= 0 || len(b) <= maxSize {
+			return continuedChunkHandler(b, false)
+		}
+
+		for start := 0; start < len(b); start += maxSize {
+			end := start + maxSize
+
+			if end > len(b) {
+				end = len(b)
+			}
+
+			if err := continuedChunkHandler(b[start:end], start > 0); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
+
+// ProcessUntil, processes the data source until the stop function returns "true" for a chunk, such as the blank line
+// ending the header section of a protocol, and then returns a reader positioned right after that chunk, made of the
+// bytes already read but not processed followed by the rest of the data source, so the remaining data, a body for
+// instance, can be handed to another consumer. The chunk that stopped the processing is not handled.
+func ProcessUntil(
+	dataSource io.Reader,
+	stop func([]byte) bool,
+	chunkHandler DataChunkHandler,
+	chunkDelimiter DataChunkDelimiter) (io.Reader, error) {
+	leftOver, err := processDataSourceInChunksUntil(
+		context.Background(),
+		dataSource,
+		defaultChunkSize,
+		0,
+		chunkHandler,
+		chunkDelimiter,
+		stop)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return io.MultiReader(bytes.NewReader(leftOver), dataSource), nil
+}
+
+// Preview, returns the first n chunks of the data source without consuming it, along with the reader that must be used
+// to process it in full afterwards. Whenever the data source can be seeked it is moved back to where it was and
+// returned itself, otherwise every byte read while previewing is kept in memory and the returned reader goes over them
+// before going on with the rest of the data source.
+func Preview(dataSource io.Reader, n int, chunkDelimiter DataChunkDelimiter) ([][]byte, io.Reader, error) {
+	seeker, seekable := dataSource.(io.Seeker)
+	start := int64(0)
+
+	var err error
+	var readBytes bytes.Buffer
+	previewSource := dataSource
+
+	if seekable {
+		start, err = seeker.Seek(0, io.SeekCurrent)
+
+		if err != nil {
+			return nil, nil, err
+		}
+	} else {
+		previewSource = io.TeeReader(dataSource, &readBytes)
+	}
+
+	chunks := make([][]byte, 0, n)
+
+	chunkHandler := func(b []byte) error {
+		chunks = append(chunks, append([]byte(nil), b...))
+		return nil
+	}
+
+	stop := func([]byte) bool {
+		return len(chunks) >= n
+	}
+
+	_, err = processDataSourceInChunksUntil(context.Background(), previewSource, defaultChunkSize, 0, chunkHandler, chunkDelimiter, stop)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !seekable {
+		return chunks, io.MultiReader(&readBytes, dataSource), nil
+	}
+
+	if _, err = seeker.Seek(start, io.SeekStart); err != nil {
+		return nil, nil, err
+	}
+
+	return chunks, dataSource, nil
+}
+
+// LimitChunkSize, wraps a DataChunkHandler so no chunk larger than maxSize bytes ever reaches it, processing stops with
+// an ErrChunkTooLarge instead. It is recommended for handlers whose cost grows with the input in ways other than memory,
+// such as recursive parsers, whose recursion depth is bounded by the size of the record they are fed.
+func LimitChunkSize(maxSize int, chunkHandler DataChunkHandler) DataChunkHandler {
+	return func(b []byte) error {
+		if len(b) > maxSize {
+			return fmt.Errorf("%w: [%d] bytes, the limit is [%d] bytes", ErrChunkTooLarge, len(b), maxSize)
+		}
+
+		return chunkHandler(b)
+	}
+}
+
+// LastChunks, processes the whole data source but keeps only its last k chunks, in a ring of k slots so the memory used
+// does not grow with the data source, and returns them in the order they were found. Data sources with less than k
+// chunks have all of them returned.
+func LastChunks(dataSource io.Reader, k int, chunkDelimiter DataChunkDelimiter) ([][]byte, error) {
+	if k <= 0 {
+		return [][]byte{}, nil
+	}
+
+	ring := make([][]byte, k)
+	total := 0
+
+	chunkHandler := func(b []byte) error {
+		// the memory of the slot being overwritten is reused, the chunk it held is no longer needed.
+		slot := total % k
+		ring[slot] = append(ring[slot][:0], b...)
+		total++
+
+		return nil
+	}
+
+	err := ProcessDataSourceInChunks(dataSource, defaultChunkSize, chunkHandler, chunkDelimiter)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if total < k {
+		return ring[:total], nil
+	}
+
+	oldest := total % k
+
+	return append(ring[oldest:], ring[:oldest]...), nil
+}
+
+// DetectGaps, wraps a DataChunkHandler so whenever the time between two consecutive chunks, as told by the timestamps
+// taken from them with the given extractor, exceeds the threshold, onGap is called with both chunks and the gap between
+// them before the latest one is handled, such as for sensor streams that go silent. An error from the extractor stops
+// the processing.
+func DetectGaps(
+	threshold time.Duration,
+	extractTimestamp func([]byte) (time.Time, error),
+	onGap func(prev, cur []byte, gap time.Duration),
+	chunkHandler DataChunkHandler) DataChunkHandler {
+	var prev []byte
+	var prevTimestamp time.Time
+	found := false
+
+	return func(b []byte) error {
+		timestamp, err := extractTimestamp(b)
+
+		if err != nil {
+			return err
+		}
+
+		if found {
+			if gap := timestamp.Sub(prevTimestamp); gap > threshold {
+				onGap(prev, b, gap)
+			}
+		}
+
+		// the memory of the chunk is reused once the handler returns, so the previous one is kept in a copy.
+		prev = append(prev[:0], b...)
+		prevTimestamp = timestamp
+		found = true
+
+		return chunkHandler(b)
+	}
+}
@@ -0,0 +1,78 @@
+package filestream
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestProcessBytesFromHandler(t *testing.T) {
+	inner := func(records ...string) string {
+		return base64.StdEncoding.EncodeToString([]byte(strings.Join(records, "\n")))
+	}
+
+	outer := strings.Join([]string{
+		fmt.Sprintf(`{"id":"a","blob":"%s"}`, inner(`{"n":1}`, `{"n":2}`)),
+		`{"id":"b"}`,
+		fmt.Sprintf(`{"id":"c","blob":"%s"}`, inner(`{"n":3}`, ``, `{"n":4}`)),
+	}, "\n")
+
+	want := []string{"a", "a/1", "a/2", "b", "c", "c/3", "c/", "c/4"}
+
+	for _, chunkSize := range chunkSizes {
+		t.Run(fmt.Sprintf("chunk size [%d]", chunkSize), func(t *testing.T) {
+			var got []string
+
+			err := ProcessString(outer, chunkSize, func(chunk []byte) error {
+				line := append([]byte(nil), chunk...)
+
+				var record struct {
+					ID   string `json:"id"`
+					Blob []byte `json:"blob"`
+				}
+
+				if err := json.Unmarshal(chunk, &record); err != nil {
+					return err
+				}
+
+				got = append(got, record.ID)
+
+				// the inner records are delimited by a separator, which reads through the same buffers as the outer
+				// processing does.
+				innerErr := ProcessBytes(record.Blob, chunkSize, func(innerChunk []byte) error {
+					var innerRecord struct {
+						N json.Number `json:"n"`
+					}
+
+					if len(innerChunk) > 0 {
+						if err := json.Unmarshal(innerChunk, &innerRecord); err != nil {
+							return err
+						}
+					}
+
+					got = append(got, record.ID+"/"+innerRecord.N.String())
+
+					return nil
+				}, DelimiteBySeparator([]byte("\n")))
+
+				if !bytes.Equal(chunk, line) {
+					t.Errorf("the outer chunk changed from %q to %q while processing the inner one", line, chunk)
+				}
+
+				return innerErr
+			}, DelimiteBySeparator([]byte("\n")))
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("got %q, want %q", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package filestream
+
+import "bytes"
+
+// DelimiteByTopLevelIndent, one implementation of DataChunkDelimiter for indentation structured text (Python like
+// blocks), each top level block, a line starting at column zero plus all the following lines indented by spaces or
+// tabs, is emitted as a single chunk. Blank lines are kept inside the block they are found in. A block is only known to
+// be complete once the first byte of the next top level line is present, so until then more data is requested.
+func DelimiteByTopLevelIndent(chunk []byte) (bool, []byte, []byte) {
+	lineStart := 0
+
+	for {
+		lineEnd := indexOfByteFrom(chunk, lineStart, newLineByte)
+
+		if lineEnd < 0 {
+			return false, chunk, nil
+		}
+
+		nextLine := lineEnd + 1
+
+		// the first byte of the next line is what tells whether the block goes on or not.
+		if nextLine >= len(chunk) {
+			return false, chunk, nil
+		}
+
+		next := chunk[nextLine]
+
+		if next != ' ' && next != '\t' && next != newLineByte {
+			leftOver := make([]byte, len(chunk)-nextLine)
+			copy(leftOver, chunk[nextLine:])
+
+			return true, chunk[:lineEnd], leftOver
+		}
+
+		lineStart = nextLine
+	}
+}
+
+func indexOfByteFrom(b []byte, from int, c byte) int {
+	i := bytes.IndexByte(b[from:], c)
+
+	if i < 0 {
+		return -1
+	}
+
+	return from + i
+}
@@ -0,0 +1,275 @@
+package filestream
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+const (
+	jsonModeUndecided = iota
+	jsonModeNDJSON
+	jsonModeArrayOpening
+	jsonModeArrayElements
+)
+
+// DelimiteByJSONAuto, builds a DataChunkDelimiter that decides by itself whether the data source is a JSON array or
+// JSON lines (NDJSON), it does so by peeking the first non-whitespace byte of the stream: a "[" means the whole stream
+// is a single array and each one of its elements is going to be emitted as a chunk, anything else means the stream is
+// NDJSON and it is delimited exactly as DelimiteByNewLine does. Both shapes produce the same chunks for the same
+// records, an empty array has no chunk at all and the whitespace before or after an array is not part of any chunk.
+// NOTE: the returned delimiter holds state about the stream being read, so a new one must be built for every data
+// source.
+func DelimiteByJSONAuto() DataChunkDelimiter {
+	mode := jsonModeUndecided
+
+	return func(chunk []byte) (bool, []byte, []byte) {
+		for {
+			if mode == jsonModeUndecided {
+				start := skipJSONWhitespace(chunk, 0)
+
+				// only whitespace so far, there is no way to tell the shape of the stream yet, the whitespace is not
+				// part of any record, so it is dropped instead of being handed over at the end of the data source.
+				if start == len(chunk) {
+					return false, chunk[start:], nil
+				}
+
+				// whatever the shape, the whitespace before the first record is not part of it.
+				chunk = chunk[start:]
+				mode = jsonModeNDJSON
+
+				if chunk[0] == '[' {
+					mode = jsonModeArrayOpening
+				}
+			}
+
+			if mode == jsonModeNDJSON {
+				return DelimiteByNewLine(chunk)
+			}
+
+			start := skipJSONWhitespace(chunk, 0)
+			opening := mode == jsonModeArrayOpening
+
+			// the opening bracket is only present before the first element, the following ones start right after the
+			// comma that separated them from the previous one.
+			if opening {
+				start = skipJSONWhitespace(chunk, start+1)
+			}
+
+			end, closesArray, found := findJSONElementEnd(chunk, start)
+
+			if !found {
+				return false, chunk, nil
+			}
+
+			mode = jsonModeArrayElements
+
+			// once the array is closed the stream could still contain another document, so the next call must peek
+			// again.
+			if closesArray {
+				mode = jsonModeUndecided
+			}
+
+			element := trimJSONWhitespace(chunk[start:end])
+
+			// an empty array has no element at all, the data after it is delimited right away.
+			if opening && closesArray && len(element) == 0 {
+				chunk = chunk[end+1:]
+				continue
+			}
+
+			leftOver := make([]byte, len(chunk)-end-1)
+			copy(leftOver, chunk[end+1:])
+
+			return true, element, leftOver
+		}
+	}
+}
+
+// findJSONElementEnd, scans a JSON array element starting at the given index and returns the index of the "," or "]"
+// that terminates it, whether that terminator is the one closing the array and whether it was found at all. Brackets
+// and braces found inside string literals are ignored.
+func findJSONElementEnd(chunk []byte, start int) (int, bool, bool) {
+	depth := 0
+	inString := false
+	escaped := false
+
+	for i := start; i < len(chunk); i++ {
+		c := chunk[i]
+
+		if inString {
+			if escaped {
+				escaped = false
+			} else if c == '\\' {
+				escaped = true
+			} else if c == '"' {
+				inString = false
+			}
+
+			continue
+		}
+
+		switch c {
+		case '"':
+			inString = true
+		case '{', '[':
+			depth++
+		case '}', ']':
+			if depth == 0 && c == ']' {
+				return i, true, true
+			}
+
+			depth--
+		case ',':
+			if depth == 0 {
+				return i, false, true
+			}
+		}
+	}
+
+	return 0, false, false
+}
+
+// DelimiteByJSONObject, one implementation of DataChunkDelimiter for concatenated JSON objects, pretty printed or not,
+// each top level object is emitted as a chunk once its closing brace is found, whatever the new lines inside it. Braces
+// found inside string literals, escaped quotes included, are ignored, and the whitespace between objects is skipped.
+// NOTE: the data source is expected to be made of objects only, anything else found between them is handed over along
+// with the next object.
+func DelimiteByJSONObject(chunk []byte) (bool, []byte, []byte) {
+	start := skipJSONWhitespace(chunk, 0)
+	depth := 0
+	inString := false
+	escaped := false
+
+	for i := start; i < len(chunk); i++ {
+		c := chunk[i]
+
+		if inString {
+			if escaped {
+				escaped = false
+			} else if c == '\\' {
+				escaped = true
+			} else if c == '"' {
+				inString = false
+			}
+
+			continue
+		}
+
+		switch c {
+		case '"':
+			inString = true
+		case '{':
+			depth++
+		case '}':
+			depth--
+
+			if depth == 0 {
+				leftOver := make([]byte, len(chunk)-i-1)
+				copy(leftOver, chunk[i+1:])
+
+				return true, chunk[start : i+1], leftOver
+			}
+		}
+	}
+
+	// the whitespace before the object is dropped right away, so it is not handed over at the end of the data source.
+	return false, chunk[start:], nil
+}
+
+func skipJSONWhitespace(b []byte, from int) int {
+	for from < len(b) && isJSONWhitespace(b[from]) {
+		from++
+	}
+
+	return from
+}
+
+func trimJSONWhitespace(b []byte) []byte {
+	start := skipJSONWhitespace(b, 0)
+	end := len(b)
+
+	for end > start && isJSONWhitespace(b[end-1]) {
+		end--
+	}
+
+	return b[start:end]
+}
+
+func isJSONWhitespace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+// DelimiteByJSONField, builds a DataChunkDelimiterWithEOF for JSON lines that groups consecutive records sharing the
+// same value for the given top level field, such as a "batch_id", each group is emitted as a single chunk containing
+// its lines. A group is only known to be complete once a line with a different value is present, the last group is
+// emitted when the data source ends, whether its last line is terminated or not. Lines that are not JSON objects or
+// lack the field are grouped together as having no value. Every line is parsed only once, the lines already known to
+// belong to the group being collected are not parsed again after the next read.
+// NOTE: the returned delimiter holds state about the stream being read, so a new one must be built for every data
+// source.
+func DelimiteByJSONField(field string) DataChunkDelimiterWithEOF {
+	// groupEnd, where the lines already known to belong to the group being collected end in the data, the new line
+	// after the last of them included, and groupValue, the value they share.
+	groupEnd := 0
+	var groupValue []byte
+
+	return func(chunk []byte, atEOF bool) (bool, []byte, []byte, error) {
+		if len(chunk) == 0 {
+			return false, chunk, nil, nil
+		}
+
+		for groupEnd < len(chunk) {
+			lineStart := groupEnd
+			lineEnd := indexOfByteFrom(chunk, lineStart, newLineByte)
+
+			if lineEnd < 0 && !atEOF {
+				return false, chunk, nil, nil
+			}
+
+			// the last line ends with the data source.
+			if lineEnd < 0 {
+				lineEnd = len(chunk)
+			}
+
+			value := jsonFieldValue(chunk[lineStart:lineEnd], field)
+
+			if lineStart > 0 && !bytes.Equal(value, groupValue) {
+				leftOver := make([]byte, len(chunk)-lineStart)
+				copy(leftOver, chunk[lineStart:])
+
+				// the line just parsed is the first one of the next group, which is collected from the left over.
+				groupValue = value
+				groupEnd = lineEnd + 1 - lineStart
+
+				return true, chunk[:lineStart-1], leftOver, nil
+			}
+
+			groupValue = value
+			groupEnd = lineEnd + 1
+		}
+
+		if !atEOF {
+			return false, chunk, nil, nil
+		}
+
+		// the last group ends with the data source, right before the new line terminating it, if there is one, since
+		// groupEnd is one byte after the data source when there is none.
+		group := chunk[:groupEnd-1]
+		groupEnd = 0
+		groupValue = nil
+
+		return true, group, nil, nil
+	}
+}
+
+// jsonFieldValue, returns the raw JSON value of the given top level field of a JSON object line, nil is returned when
+// the line is not an object or there is no such field.
+func jsonFieldValue(line []byte, field string) []byte {
+	var fields map[string]json.RawMessage
+
+	if err := json.Unmarshal(line, &fields); err != nil {
+		return nil
+	}
+
+	return fields[field]
+}
@@ -0,0 +1,137 @@
+package filestream
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDelimiteByJSONField(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []string
+	}{
+		{
+			name: "groups",
+			data: "{\"b\":1}\n{\"b\":1}\n{\"b\":2}\n",
+			want: []string{"{\"b\":1}\n{\"b\":1}", "{\"b\":2}"},
+		},
+		{
+			name: "key change without trailing new line",
+			data: "{\"b\":1}\n{\"b\":1}\n{\"b\":2}",
+			want: []string{"{\"b\":1}\n{\"b\":1}", "{\"b\":2}"},
+		},
+		{
+			name: "single group without trailing new line",
+			data: "{\"b\":1}\n{\"b\":1}",
+			want: []string{"{\"b\":1}\n{\"b\":1}"},
+		},
+		{
+			name: "lines without the field",
+			data: "{\"a\":1}\nnot json\n{\"b\":1}\n",
+			want: []string{"{\"a\":1}\nnot json", "{\"b\":1}"},
+		},
+		{
+			name: "alternating groups",
+			data: "{\"b\":1}\n{\"b\":2}\n{\"b\":1}\n",
+			want: []string{"{\"b\":1}", "{\"b\":2}", "{\"b\":1}"},
+		},
+		{
+			name: "empty",
+			data: "",
+			want: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := collectChunks(t, tt.data, withDelimiterWithEOF(func() DataChunkDelimiterWithEOF {
+				return DelimiteByJSONField("b")
+			}))
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestDelimiteByJSONFieldParsesEveryLineOnce, the lines of a group are not parsed again after every read, so the data
+// given to the delimiter grows without the lines already parsed being looked at again.
+func TestDelimiteByJSONFieldParsesEveryLineOnce(t *testing.T) {
+	chunkDelimiter := DelimiteByJSONField("b")
+	data := []byte("{\"b\":1}\n")
+
+	if enough, _, _, _ := chunkDelimiter(data, false); enough {
+		t.Fatalf("a group was found before the next one started")
+	}
+
+	// the first line lacks the field now, so it would be a group of its own if it was parsed again.
+	data = append([]byte("{\"x\":1}\n"), "{\"b\":1}\n{\"b\":2}\n"...)
+
+	enough, group, leftOver, _ := chunkDelimiter(data, false)
+
+	if !enough || string(group) != "{\"x\":1}\n{\"b\":1}" || string(leftOver) != "{\"b\":2}\n" {
+		t.Errorf("got %v %q %q", enough, group, leftOver)
+	}
+}
+
+func TestDelimiteByJSONAuto(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []string
+	}{
+		{"array", `[1, {"a": [2, 3]}, "x,]"]`, []string{`1`, `{"a": [2, 3]}`, `"x,]"`}},
+		{"pretty printed array", "[\n  1,\n  2\n]\n", []string{"1", "2"}},
+		{"whitespace after the array", "[1, 2] \n", []string{"1", "2"}},
+		{"empty array", "[]", []string{}},
+		{"empty array with whitespace", " [ \n ] \n", []string{}},
+		{"arrays in a row", "[] [1]\n[2]", []string{"1", "2"}},
+		{"NDJSON", "{\"a\":1}\n{\"a\":2}\n", []string{`{"a":1}`, `{"a":2}`}},
+		{"NDJSON after whitespace", "\n {\"a\":1}\n", []string{`{"a":1}`}},
+		{"empty", "", []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := collectChunks(t, tt.data, withDelimiter(DelimiteByJSONAuto))
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+	t.Run("same records as an array and as NDJSON", func(t *testing.T) {
+		records := []string{`{"a":1}`, `{"b":{"c":[2,3]}}`, `{"d":"x,]"}`}
+
+		fromArray, err := collectChunks(t, "["+strings.Join(records, ",")+"]", withDelimiter(DelimiteByJSONAuto))
+
+		if err != nil {
+			t.Fatalf("unexpected error for the array: %v", err)
+		}
+
+		fromNDJSON, err := collectChunks(t, strings.Join(records, "\n")+"\n", withDelimiter(DelimiteByJSONAuto))
+
+		if err != nil {
+			t.Fatalf("unexpected error for the NDJSON: %v", err)
+		}
+
+		if !reflect.DeepEqual(fromArray, fromNDJSON) {
+			t.Errorf("got %q from the array but %q from the NDJSON", fromArray, fromNDJSON)
+		}
+
+		if !reflect.DeepEqual(fromArray, records) {
+			t.Errorf("got %q, want %q", fromArray, records)
+		}
+	})
+}
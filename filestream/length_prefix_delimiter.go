@@ -0,0 +1,76 @@
+package filestream
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// ErrFrameTooLarge, returned by the delimiters built by DelimiteByLengthPrefix for a frame declaring a length beyond the
+// limit given.
+var ErrFrameTooLarge = errors.New("frame too large")
+
+// DelimiteByLengthPrefix, builds a DataChunkDelimiterWithError for binary framing where every payload is preceded by
+// its length, written in prefixBytes bytes, from 1 to 8, in the given byte order. Only the payload is emitted, and only
+// once it is all present, no matter how many reads it takes. A frame declaring a length larger than maxLength is an
+// error, since it is more likely corrupt data than a real frame, and trying to collect it could exhaust the memory, a
+// maxLength of zero means there is no limit.
+// NOTE: it is meant for NewProcessor along with WithDelimiterWithError.
+func DelimiteByLengthPrefix(prefixBytes int, byteOrder binary.ByteOrder, maxLength uint64) DataChunkDelimiterWithError {
+	return func(chunk []byte) (bool, []byte, []byte, error) {
+		if prefixBytes < 1 || prefixBytes > 8 {
+			return false, chunk, nil, fmt.Errorf("invalid length prefix size [%d], it must be from 1 to 8 bytes", prefixBytes)
+		}
+
+		if len(chunk) < prefixBytes {
+			return false, chunk, nil, nil
+		}
+
+		length := readLengthPrefix(chunk[:prefixBytes], byteOrder)
+
+		if maxLength > 0 && length > maxLength {
+			return false, chunk, nil, fmt.Errorf(
+				"%w: [%d] bytes declared, the limit is [%d] bytes",
+				ErrFrameTooLarge,
+				length,
+				maxLength)
+		}
+
+		if uint64(len(chunk)-prefixBytes) < length {
+			return false, chunk, nil, nil
+		}
+
+		frameEnd := prefixBytes + int(length)
+
+		leftOver := make([]byte, len(chunk)-frameEnd)
+		copy(leftOver, chunk[frameEnd:])
+
+		return true, chunk[prefixBytes:frameEnd], leftOver, nil
+	}
+}
+
+// readLengthPrefix, decodes a length written in 1 to 8 bytes in the given byte order, odd sizes are only understood as
+// little endian or big endian.
+func readLengthPrefix(prefix []byte, byteOrder binary.ByteOrder) uint64 {
+	switch len(prefix) {
+	case 1:
+		return uint64(prefix[0])
+	case 2:
+		return uint64(byteOrder.Uint16(prefix))
+	case 4:
+		return uint64(byteOrder.Uint32(prefix))
+	case 8:
+		return byteOrder.Uint64(prefix)
+	}
+
+	padded := make([]byte, 8)
+
+	if byteOrder == binary.LittleEndian {
+		copy(padded, prefix)
+		return binary.LittleEndian.Uint64(padded)
+	}
+
+	copy(padded[8-len(prefix):], prefix)
+
+	return binary.BigEndian.Uint64(padded)
+}
@@ -0,0 +1,26 @@
+package filestream
+
+import "bytes"
+
+// DelimiteByLine, one implementation of DataChunkDelimiter that, unlike DelimiteByNewLine, understands both "\n" and
+// "\r\n" as line terminators, so files generated on Windows do not produce chunks ending with a carriage return. Only a
+// carriage return right before the new line is part of the terminator, a lone "\r" anywhere else is kept as data, and
+// one found at the end of a read is only dropped once the new line that follows it arrives.
+func DelimiteByLine(chunk []byte) (bool, []byte, []byte) {
+	lineEnd := bytes.IndexByte(chunk, newLineByte)
+
+	if lineEnd < 0 {
+		return false, chunk, nil
+	}
+
+	leftOver := make([]byte, len(chunk)-lineEnd-1)
+	copy(leftOver, chunk[lineEnd+1:])
+
+	line := chunk[:lineEnd]
+
+	if len(line) > 0 && line[len(line)-1] == carriageReturnByte {
+		line = line[:len(line)-1]
+	}
+
+	return true, line, leftOver
+}
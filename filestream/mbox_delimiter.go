@@ -0,0 +1,67 @@
+package filestream
+
+import "bytes"
+
+var (
+	mboxSeparator       = []byte("\nFrom ")
+	mboxEscapedFromLine = []byte("From ")
+)
+
+// DelimiteByMbox, builds a DataChunkDelimiterWithEOF for mbox files, where every message starts with a line beginning
+// with "From ", each message, including its "From " line, is emitted as a chunk. A message is only known to be complete
+// once the "From " line of the next message is present, the last one is emitted when the data source ends. When
+// unescapeFrom is set, the lines quoted inside the messages body as ">From " (or ">>From " and so on, as mboxrd does)
+// lose one of their leading ">", the last message included.
+func DelimiteByMbox(unescapeFrom bool) DataChunkDelimiterWithEOF {
+	return func(chunk []byte, atEOF bool) (bool, []byte, []byte, error) {
+		if len(chunk) == 0 {
+			return false, chunk, nil, nil
+		}
+
+		// the search starts right after the first byte so the "From " line of the current message is not found.
+		separatorIndex := bytes.Index(chunk[1:], mboxSeparator)
+
+		if separatorIndex < 0 && !atEOF {
+			return false, chunk, nil, nil
+		}
+
+		// the last message ends with the data source, the new line after it is dropped just as the one before the
+		// "From " line of the next message is for the others.
+		message := removeTrailingNewLine(chunk)
+		leftOver := []byte(nil)
+
+		if separatorIndex >= 0 {
+			messageEnd := separatorIndex + 1
+
+			leftOver = make([]byte, len(chunk)-messageEnd-1)
+			copy(leftOver, chunk[messageEnd+1:])
+
+			message = chunk[:messageEnd]
+		}
+
+		if unescapeFrom {
+			message = unescapeMboxFromLines(message)
+		}
+
+		return true, message, leftOver, nil
+	}
+}
+
+// unescapeMboxFromLines, removes one leading ">" of every line made of one or more ">" followed by "From ".
+func unescapeMboxFromLines(message []byte) []byte {
+	lines := bytes.Split(message, []byte{newLineByte})
+
+	for i, line := range lines {
+		quotes := 0
+
+		for quotes < len(line) && line[quotes] == '>' {
+			quotes++
+		}
+
+		if quotes > 0 && bytes.HasPrefix(line[quotes:], mboxEscapedFromLine) {
+			lines[i] = line[1:]
+		}
+	}
+
+	return bytes.Join(lines, []byte{newLineByte})
+}
@@ -0,0 +1,59 @@
+package filestream
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDelimiteByMbox(t *testing.T) {
+	tests := []struct {
+		name         string
+		data         string
+		unescapeFrom bool
+		want         []string
+	}{
+		{
+			name: "messages",
+			data: "From a\nx\nFrom b\ny\n",
+			want: []string{"From a\nx", "From b\ny"},
+		},
+		{
+			name:         "unescaped messages",
+			data:         "From a\n>From x\nFrom b\n>>From y\n",
+			unescapeFrom: true,
+			want:         []string{"From a\nFrom x", "From b\n>From y"},
+		},
+		{
+			name:         "last message without trailing new line",
+			data:         "From a\nx\nFrom b\n>From y",
+			unescapeFrom: true,
+			want:         []string{"From a\nx", "From b\nFrom y"},
+		},
+		{
+			name: "quoted lines kept",
+			data: "From a\n>From x",
+			want: []string{"From a\n>From x"},
+		},
+		{
+			name: "empty",
+			data: "",
+			want: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := collectChunks(t, tt.data, withDelimiterWithEOF(func() DataChunkDelimiterWithEOF {
+				return DelimiteByMbox(tt.unescapeFrom)
+			}))
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package filestream
+
+import (
+	"errors"
+	"io"
+	"time"
+)
+
+var errMergeAborted = errors.New("merge aborted")
+
+// mergeSource, the chunks of one of the data sources being merged, produced one at a time by its own goroutine.
+type mergeSource struct {
+	chunks chan []byte
+	result chan error
+}
+
+// MergeByTimestamp, merges several data sources whose chunks are each in time order, such as log files with timestamp
+// prefixed lines, into a single stream in time order. The head chunk of every data source is held, its timestamp is
+// taken with the given extractor and the earliest one is handed to the handler, to be replaced by the next chunk of the
+// same data source. Chunks with the same timestamp are handed over in the order their data sources were given. An error
+// from the extractor, the handler or any of the data sources stops the merge, an ErrStopProcessing from the handler
+// stops it the same way but nil is returned.
+// NOTE: the delimiter is used for all the data sources at the same time, so it must not hold any state about the data,
+// which rules out delimiters such as the one built by DelimiteByJSONAuto.
+func MergeByTimestamp(
+	dataSources []io.Reader,
+	extractTimestamp func([]byte) (time.Time, error),
+	chunkHandler DataChunkHandler,
+	chunkDelimiter DataChunkDelimiter) error {
+	done := make(chan struct{})
+	defer close(done)
+
+	sources := make([]mergeSource, len(dataSources))
+
+	for i, dataSource := range dataSources {
+		source := mergeSource{chunks: make(chan []byte), result: make(chan error, 1)}
+		sources[i] = source
+
+		go func(dataSource io.Reader) {
+			sourceHandler := func(chunk []byte) error {
+				select {
+				case source.chunks <- append([]byte(nil), chunk...):
+					return nil
+				case <-done:
+					return errMergeAborted
+				}
+			}
+
+			err := ProcessDataSourceInChunks(dataSource, defaultChunkSize, sourceHandler, chunkDelimiter)
+			close(source.chunks)
+			source.result <- err
+		}(dataSource)
+	}
+
+	heads := make([][]byte, len(sources))
+	timestamps := make([]time.Time, len(sources))
+	found := make([]bool, len(sources))
+
+	// next, replaces the head chunk of the data source by its next one, once the data source is over its result is
+	// checked so a read error is not mistaken for its end.
+	next := func(i int) error {
+		chunk, ok := <-sources[i].chunks
+
+		if !ok {
+			found[i] = false
+			return <-sources[i].result
+		}
+
+		timestamp, err := extractTimestamp(chunk)
+
+		if err != nil {
+			return err
+		}
+
+		heads[i], timestamps[i], found[i] = chunk, timestamp, true
+
+		return nil
+	}
+
+	for i := range sources {
+		if err := next(i); err != nil {
+			return err
+		}
+	}
+
+	for {
+		earliest := -1
+
+		for i := range sources {
+			if found[i] && (earliest < 0 || timestamps[i].Before(timestamps[earliest])) {
+				earliest = i
+			}
+		}
+
+		if earliest < 0 {
+			return nil
+		}
+
+		if err := chunkHandler(heads[earliest]); err != nil {
+			if errors.Is(err, ErrStopProcessing) {
+				return nil
+			}
+
+			return err
+		}
+
+		if err := next(earliest); err != nil {
+			return err
+		}
+	}
+}
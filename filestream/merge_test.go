@@ -0,0 +1,70 @@
+package filestream
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMergeByTimestamp(t *testing.T) {
+	first := "2021-01-01T00:00:01Z a1\n2021-01-01T00:00:03Z a3\n2021-01-01T00:00:05Z a5\n"
+	second := "2021-01-01T00:00:02Z b2\n2021-01-01T00:00:03Z b3\n2021-01-01T00:00:06Z b6\n"
+
+	extractTimestamp := func(chunk []byte) (time.Time, error) {
+		return time.Parse(time.RFC3339, string(chunk[:bytes.IndexByte(chunk, ' ')]))
+	}
+
+	tests := []struct {
+		name   string
+		stopAt string
+		want   []string
+	}{
+		{"whole data sources", "", []string{"a1", "b2", "a3", "b3", "a5", "b6"}},
+		{"stopped by the handler", "b3", []string{"a1", "b2", "a3", "b3"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+
+			err := MergeByTimestamp(
+				[]io.Reader{strings.NewReader(first), strings.NewReader(second)},
+				extractTimestamp,
+				func(chunk []byte) error {
+					name := string(chunk[bytes.IndexByte(chunk, ' ')+1:])
+					got = append(got, name)
+
+					if name == tt.stopAt {
+						return ErrStopProcessing
+					}
+
+					return nil
+				},
+				DelimiteByNewLine)
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("handler error", func(t *testing.T) {
+		err := MergeByTimestamp(
+			[]io.Reader{strings.NewReader(first), strings.NewReader(second)},
+			extractTimestamp,
+			func([]byte) error { return errBadChunk },
+			DelimiteByNewLine)
+
+		if !errors.Is(err, errBadChunk) {
+			t.Errorf("got error [%v], want [%v]", err, errBadChunk)
+		}
+	})
+}
@@ -0,0 +1,133 @@
+package filestream
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrChunkNotFound, returned by IndexChunks for a chunk that is not among the bytes consumed from the data source for
+// it, such as one changed by the delimiter, there being no span of the data source holding it.
+var ErrChunkNotFound = errors.New("chunk not found in the data source")
+
+// ChunkMeta, where a chunk was found in the data source, meant for error reporting.
+type ChunkMeta struct {
+	// Offset, the position in the data source, in bytes, where the bytes consumed for the chunk start, since delimiter
+	// bytes are consumed too, the offset of a chunk is right after the terminator of the previous one.
+	Offset int64
+	// Index, the position of the chunk among all the chunks of the data source, starting at one.
+	Index int
+}
+
+// ChunkError, the error returned by the processing whenever the handler fails, carrying where the chunk the handler
+// failed for was found, errors.Is and errors.As see through it the error returned by the handler.
+type ChunkError struct {
+	ChunkMeta
+	Err error
+}
+
+func (e *ChunkError) Error() string {
+	return fmt.Sprintf("chunk [%d] at offset [%d]: %v", e.Index, e.Offset, e.Err)
+}
+
+func (e *ChunkError) Unwrap() error {
+	return e.Err
+}
+
+// DataChunkHandlerWithMeta, same as DataChunkHandler but it also receives the ChunkMeta of the chunk.
+type DataChunkHandlerWithMeta func(chunk []byte, meta ChunkMeta) error
+
+// ProcessDataSourceInChunksWithMeta, same as ProcessDataSourceInChunks but the handler also receives where each chunk
+// was found in the data source.
+// NOTE: offsets are computed from the bytes the delimiter leaves over, so they are only accurate for delimiters that
+// hand back the left over bytes as they were found in the data source, which all the delimiters in this package do.
+func ProcessDataSourceInChunksWithMeta(
+	dataSource io.Reader,
+	chunkSize int,
+	chunkHandler DataChunkHandlerWithMeta,
+	chunkDelimiter DataChunkDelimiter) error {
+	scanner := newChunkScanner(context.Background(), dataSource, chunkSize, 0, chunkDelimiter)
+
+	for scanner.Scan() {
+		meta := ChunkMeta{Offset: scanner.chunkOffset, Index: scanner.chunkIndex}
+		err := chunkHandler(scanner.Bytes(), meta)
+
+		if errors.Is(err, ErrStopProcessing) {
+			return nil
+		}
+
+		if err != nil {
+			return &ChunkError{ChunkMeta: meta, Err: err}
+		}
+	}
+
+	return scanner.Err()
+}
+
+// ChunkSpan, the position of a chunk in the data source, from the byte it starts at, Start, up to the byte right after
+// its last one, End, so the chunk can be read again later with an io.SectionReader.
+type ChunkSpan struct {
+	Start int64
+	End   int64
+}
+
+// IndexChunks, delimits the first size bytes of the data source returning the ChunkSpan of every chunk found, without
+// keeping the chunks themselves, such as to build an index of the records of a large file to seek to them later. The
+// span of a chunk holds exactly the bytes handed to the handler, so the delimiter terminating it is not included, nor
+// are the bytes the delimiter drops, such as the whitespace DelimiteByJSONObject drops between the objects, since the
+// chunk is looked for among the bytes consumed for it. A chunk that is not there, changed by the delimiter, stops the
+// indexing with an ErrChunkNotFound.
+// NOTE: the chunk is looked for from the first byte consumed for it, so a delimiter dropping bytes that hold the chunk
+// as well gets the span of those instead, none of the delimiters in this package does.
+func IndexChunks(dataSource io.ReaderAt, size int64, chunkDelimiter DataChunkDelimiter) ([]ChunkSpan, error) {
+	source := &recordingReader{src: io.NewSectionReader(dataSource, 0, size)}
+	scanner := newChunkScanner(context.Background(), source, defaultChunkSize, 0, chunkDelimiter)
+
+	var spans []ChunkSpan
+
+	// recordedFrom, the offset in the data source of the first byte recorded, the bytes before it were already indexed.
+	var recordedFrom int64
+
+	for scanner.Scan() {
+		consumed := source.data[scanner.chunkOffset-recordedFrom : scanner.consumed-recordedFrom]
+		start := bytes.Index(consumed, scanner.Bytes())
+
+		if start < 0 {
+			return spans, fmt.Errorf(
+				"%w: chunk [%d] is not among the [%d] bytes consumed for it at offset [%d]",
+				ErrChunkNotFound,
+				scanner.chunkIndex,
+				len(consumed),
+				scanner.chunkOffset)
+		}
+
+		spans = append(spans, ChunkSpan{
+			Start: scanner.chunkOffset + int64(start),
+			End:   scanner.chunkOffset + int64(start+len(scanner.Bytes())),
+		})
+
+		source.data = source.data[:copy(source.data, source.data[scanner.consumed-recordedFrom:])]
+		recordedFrom = scanner.consumed
+	}
+
+	return spans, scanner.Err()
+}
+
+// recordingReader, an io.Reader keeping a copy of all the bytes read from the underlying one, for as long as they are
+// not dropped from data.
+type recordingReader struct {
+	src  io.Reader
+	data []byte
+}
+
+func (r *recordingReader) Read(p []byte) (int, error) {
+	n, err := r.src.Read(p)
+
+	if n > 0 {
+		r.data = append(r.data, p[:n]...)
+	}
+
+	return n, err
+}
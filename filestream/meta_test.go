@@ -0,0 +1,61 @@
+package filestream
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestIndexChunks(t *testing.T) {
+	tests := []struct {
+		name           string
+		data           string
+		chunkDelimiter DataChunkDelimiter
+		want           []string
+	}{
+		{"lines", "first\n\nsecond\nlast", DelimiteByNewLine, []string{"first", "", "second", "last"}},
+		{"carriage returns", "first\r\nsecond\r\n", DelimiteByLine, []string{"first", "second"}},
+		{
+			"JSON objects with whitespace between them",
+			"  {\"a\":1}\n\n\t{\"b\":{\"c\":\"}\"}}   {\"d\":2}\n",
+			DelimiteByJSONObject,
+			[]string{`{"a":1}`, `{"b":{"c":"}"}}`, `{"d":2}`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := []byte(tt.data)
+			spans, err := IndexChunks(bytes.NewReader(data), int64(len(data)), tt.chunkDelimiter)
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var got []string
+
+			for _, span := range spans {
+				got = append(got, string(data[span.Start:span.End]))
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("spans %v bracket %q, want %q", spans, got, tt.want)
+			}
+		})
+	}
+
+	t.Run("chunk changed by the delimiter", func(t *testing.T) {
+		data := []byte("a b\nc d\n")
+		withoutSpaces := func(chunk []byte) (bool, []byte, []byte) {
+			enough, line, leftOver := DelimiteByNewLine(chunk)
+			return enough, bytes.ReplaceAll(line, []byte(" "), nil), leftOver
+		}
+
+		_, err := IndexChunks(bytes.NewReader(data), int64(len(data)), withoutSpaces)
+
+		if !errors.Is(err, ErrChunkNotFound) {
+			t.Errorf("got error [%v], want [%v]", err, ErrChunkNotFound)
+		}
+	})
+}
@@ -0,0 +1,48 @@
+package filestream
+
+import "bytes"
+
+var paragraphSeparator = []byte{newLineByte, newLineByte}
+
+// DelimiteByParagraph, one implementation of DataChunkDelimiterWithEOF for prose or email like text, each paragraph is
+// emitted as a chunk, being paragraphs separated by one or more blank lines, meaning a run of two or more new lines.
+// Since a run of new lines found at the end of the chunk could go on in the next read, the paragraph before it is only
+// emitted once something different than a new line comes after the run, or once the data source ends, which ends the
+// last paragraph as well, dropping the new lines after it.
+func DelimiteByParagraph(chunk []byte, atEOF bool) (bool, []byte, []byte, error) {
+	// new lines before the first paragraph are not part of any paragraph.
+	start := 0
+
+	for start < len(chunk) && chunk[start] == newLineByte {
+		start++
+	}
+
+	separatorIndex := bytes.Index(chunk[start:], paragraphSeparator)
+
+	if separatorIndex < 0 && !atEOF {
+		return false, chunk, nil, nil
+	}
+
+	// the last paragraph ends with the data source.
+	if separatorIndex < 0 {
+		paragraph := bytes.TrimRight(chunk[start:], string(newLineByte))
+
+		return len(paragraph) > 0, paragraph, nil, nil
+	}
+
+	separatorIndex += start
+	separatorEnd := separatorIndex + len(paragraphSeparator)
+
+	for separatorEnd < len(chunk) && chunk[separatorEnd] == newLineByte {
+		separatorEnd++
+	}
+
+	if separatorEnd == len(chunk) && !atEOF {
+		return false, chunk, nil, nil
+	}
+
+	leftOver := make([]byte, len(chunk)-separatorEnd)
+	copy(leftOver, chunk[separatorEnd:])
+
+	return true, chunk[start:separatorIndex], leftOver, nil
+}
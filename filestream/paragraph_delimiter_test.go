@@ -0,0 +1,38 @@
+package filestream
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDelimiteByParagraph(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []string
+	}{
+		{"paragraphs", "a\nb\n\nc\n", []string{"a\nb", "c"}},
+		{"many blank lines", "a\n\n\n\nb", []string{"a", "b"}},
+		{"leading new lines", "\n\na\n\nb\n\n", []string{"a", "b"}},
+		{"last paragraph with a new line", "a\n\nb\nc\n", []string{"a", "b\nc"}},
+		{"single paragraph", "a\nb", []string{"a\nb"}},
+		{"only new lines", "\n\n\n", []string{}},
+		{"empty", "", []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := collectChunks(t, tt.data, func() []Option {
+				return []Option{WithDelimiterWithEOF(DelimiteByParagraph)}
+			})
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
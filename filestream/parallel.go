@@ -0,0 +1,341 @@
+package filestream
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// DataChunkTransformer, function that turns a chunk into the bytes that should be written in its place.
+type DataChunkTransformer func([]byte) ([]byte, error)
+
+var (
+	errTransformAborted  = errors.New("transform aborted")
+	errProcessingAborted = errors.New("processing aborted")
+)
+
+// orderedChunk, a chunk tagged with its position in the data source, so it can be put back in order after being
+// transformed concurrently.
+type orderedChunk struct {
+	index int
+	meta  ChunkMeta
+	data  []byte
+	err   error
+}
+
+// chunkJob, a chunk handed to a worker along with where it was found in the data source, so the errors of its handler
+// can tell it.
+type chunkJob struct {
+	meta  ChunkMeta
+	data  []byte
+	shard int
+}
+
+// currentChunkMeta, the ChunkMeta of the chunk the processor is handing over.
+func currentChunkMeta(p *Processor) ChunkMeta {
+	return ChunkMeta{Offset: p.scanner.chunkOffset, Index: p.scanner.chunkIndex}
+}
+
+// TransformParallelOrdered, reads and delimits the data source sequentially, transforms its chunks concurrently in the
+// given number of worker goroutines and writes the transformed chunks to the destination in the same order they were
+// found in the data source, by holding the ones that finish early in a reorder buffer. The bytes returned by the
+// transformer are written as they are, so they must include any separator the output needs. Processing stops at the
+// first error in the order of the data source, be it from the transformer, wrapped in a ChunkError telling where its
+// chunk was found, or from the writer, after all the chunks that came before it were written.
+// NOTE: at most twice the number of workers chunks are read ahead of the one being waited for, which bounds the reorder
+// buffer when a chunk takes much longer than the others to be transformed.
+func TransformParallelOrdered(
+	src io.Reader,
+	dst io.Writer,
+	workers int,
+	transformer DataChunkTransformer,
+	chunkDelimiter DataChunkDelimiter,
+	opts ...ConcurrentOption) error {
+	if workers < 1 {
+		workers = 1
+	}
+
+	group := newConcurrentConfig(opts).group
+	workers = group.acquire(workers)
+
+	jobs := make(chan orderedChunk)
+	results := make(chan orderedChunk)
+	abort := make(chan struct{})
+	inFlight := make(chan struct{}, 2*workers)
+	readResult := make(chan error, 1)
+
+	go func() {
+		index := 0
+		processor := NewProcessor(src, WithDelimiter(chunkDelimiter))
+
+		chunkHandler := func(b []byte) error {
+			select {
+			case inFlight <- struct{}{}:
+			case <-abort:
+				return errTransformAborted
+			}
+
+			// the chunk is copied since it is going to be used by another goroutine after the handler returns.
+			job := orderedChunk{index: index, meta: currentChunkMeta(processor), data: append([]byte(nil), b...)}
+			index++
+
+			select {
+			case jobs <- job:
+				return nil
+			case <-abort:
+				return errTransformAborted
+			}
+		}
+
+		readResult <- processor.Run(chunkHandler)
+		close(jobs)
+	}()
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			defer group.release()
+
+			for job := range jobs {
+				data, err := transformer(job.data)
+
+				if err != nil {
+					err = &ChunkError{ChunkMeta: job.meta, Err: err}
+				}
+
+				results <- orderedChunk{index: job.index, data: data, err: err}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	var firstErr error
+	pending := make(map[int]orderedChunk)
+	next := 0
+
+	// results are consumed until the workers are all done, even after an error, so none of them is left blocked.
+	for result := range results {
+		if firstErr != nil {
+			continue
+		}
+
+		pending[result.index] = result
+
+		for {
+			ready, found := pending[next]
+
+			if !found {
+				break
+			}
+
+			delete(pending, next)
+			next++
+			<-inFlight
+
+			err := ready.err
+
+			if err == nil {
+				_, err = dst.Write(ready.data)
+			}
+
+			if err != nil {
+				firstErr = err
+				close(abort)
+
+				break
+			}
+		}
+	}
+
+	if firstErr != nil {
+		return firstErr
+	}
+
+	return <-readResult
+}
+
+// ProcessDataSourceInChunksConcurrent, same as ProcessDataSourceInChunks but the chunks are handled concurrently by the
+// given number of worker goroutines, while the data source is still read and delimited sequentially in the calling
+// goroutine, which suits handlers doing CPU bound work such as unmarshalling JSON. The first error returned by the
+// handler is the one returned, wrapped in a ChunkError just as in ProcessDataSourceInChunks, once it happens no other
+// chunk is read or handed to the workers and the processing stops as soon as the chunks being handled at that moment
+// are done, an ErrStopProcessing stops it the same way but nil is returned.
+// NOTE: chunks are handled in no particular order, TransformParallelOrdered should be used when the order matters.
+func ProcessDataSourceInChunksConcurrent(
+	dataSource io.Reader,
+	chunkSize int,
+	workers int,
+	chunkHandler DataChunkHandler,
+	chunkDelimiter DataChunkDelimiter,
+	opts ...ConcurrentOption) error {
+	if workers < 1 {
+		workers = 1
+	}
+
+	group := newConcurrentConfig(opts).group
+	workers = group.acquire(workers)
+
+	jobs := make(chan chunkJob)
+	abort := make(chan struct{})
+
+	var firstErr error
+	var abortOnce sync.Once
+	var wg sync.WaitGroup
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			defer group.release()
+
+			for job := range jobs {
+				err := chunkHandler(job.data)
+
+				if err != nil {
+					abortOnce.Do(func() {
+						firstErr = &ChunkError{ChunkMeta: job.meta, Err: err}
+						close(abort)
+					})
+				}
+			}
+		}()
+	}
+
+	processor := NewProcessor(dataSource, WithChunkSize(chunkSize), WithDelimiter(chunkDelimiter))
+
+	dispatcher := func(b []byte) error {
+		// the chunk is copied since it is going to be used by another goroutine after the handler returns.
+		job := chunkJob{meta: currentChunkMeta(processor), data: append([]byte(nil), b...)}
+
+		select {
+		case <-abort:
+			return errProcessingAborted
+		default:
+		}
+
+		select {
+		case jobs <- job:
+			return nil
+		case <-abort:
+			return errProcessingAborted
+		}
+	}
+
+	err := processor.Run(dispatcher)
+	close(jobs)
+	wg.Wait()
+
+	// a handler stopping the processing is not a failure, just as in ProcessDataSourceInChunks.
+	if errors.Is(firstErr, ErrStopProcessing) {
+		return nil
+	}
+
+	if firstErr != nil {
+		return firstErr
+	}
+
+	return err
+}
+
+// ProcessSharded, same as ProcessDataSourceInChunksConcurrent but every chunk is routed to one of the handlers by the
+// key the key function extracts from it, so all the chunks with the same key are handled by the same handler, each
+// one running in its own goroutine, which lets handlers keep state per key without any locking. The chunks of a shard
+// are handled in the order they were found in the data source, while different shards make progress independently.
+// The number of shards must match the number of handlers given.
+// NOTE: along with WithWorkerGroup there may be fewer goroutines than shards, then a goroutine handles many shards,
+// one chunk at a time, so every handler is still only ever called by a single goroutine.
+func ProcessSharded(
+	dataSource io.Reader,
+	shards int,
+	keyFn func([]byte) uint64,
+	chunkHandlers []DataChunkHandler,
+	chunkDelimiter DataChunkDelimiter,
+	opts ...ConcurrentOption) error {
+	if shards < 1 || shards != len(chunkHandlers) {
+		return fmt.Errorf(
+			"[%d] shards were asked for with [%d] handlers, there must be one handler per shard",
+			shards,
+			len(chunkHandlers))
+	}
+
+	group := newConcurrentConfig(opts).group
+
+	// every shard is handled by a single worker, which handles every shard whose number it gets modulo the workers.
+	jobs := make([]chan chunkJob, group.acquire(shards))
+	abort := make(chan struct{})
+
+	var firstErr error
+	var abortOnce sync.Once
+	var wg sync.WaitGroup
+
+	for i := range jobs {
+		jobs[i] = make(chan chunkJob)
+		wg.Add(1)
+
+		go func(workerJobs <-chan chunkJob) {
+			defer wg.Done()
+			defer group.release()
+
+			for job := range workerJobs {
+				err := chunkHandlers[job.shard](job.data)
+
+				if err != nil {
+					abortOnce.Do(func() {
+						firstErr = &ChunkError{ChunkMeta: job.meta, Err: err}
+						close(abort)
+					})
+				}
+			}
+		}(jobs[i])
+	}
+
+	processor := NewProcessor(dataSource, WithDelimiter(chunkDelimiter))
+
+	dispatcher := func(b []byte) error {
+		// the chunk is copied since it is going to be used by another goroutine after the handler returns.
+		job := chunkJob{meta: currentChunkMeta(processor), data: append([]byte(nil), b...)}
+		job.shard = int(keyFn(job.data) % uint64(shards))
+
+		select {
+		case <-abort:
+			return errProcessingAborted
+		default:
+		}
+
+		select {
+		case jobs[job.shard%len(jobs)] <- job:
+			return nil
+		case <-abort:
+			return errProcessingAborted
+		}
+	}
+
+	err := processor.Run(dispatcher)
+
+	for _, workerJobs := range jobs {
+		close(workerJobs)
+	}
+
+	wg.Wait()
+
+	// a handler stopping the processing is not a failure, just as in ProcessDataSourceInChunks.
+	if errors.Is(firstErr, ErrStopProcessing) {
+		return nil
+	}
+
+	if firstErr != nil {
+		return firstErr
+	}
+
+	return err
+}
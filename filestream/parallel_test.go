@@ -0,0 +1,163 @@
+package filestream
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+var errBadChunk = errors.New("bad chunk")
+
+func TestConcurrentChunkError(t *testing.T) {
+	data := "a\nbb\nbad\nc\nd\n"
+	wantMeta := ChunkMeta{Index: 3, Offset: 5}
+
+	failOnBad := func(b []byte) error {
+		if string(b) == "bad" {
+			return errBadChunk
+		}
+
+		return nil
+	}
+
+	tests := []struct {
+		name string
+		run  func() error
+	}{
+		{
+			"TransformParallelOrdered",
+			func() error {
+				return TransformParallelOrdered(strings.NewReader(data), io.Discard, 3, func(b []byte) ([]byte, error) {
+					return b, failOnBad(b)
+				}, DelimiteByNewLine)
+			},
+		},
+		{
+			"ProcessDataSourceInChunksConcurrent",
+			func() error {
+				return ProcessDataSourceInChunksConcurrent(strings.NewReader(data), 4, 3, failOnBad, DelimiteByNewLine)
+			},
+		},
+		{
+			"ProcessSharded",
+			func() error {
+				keyFn := func(b []byte) uint64 { return uint64(len(b)) }
+				handlers := []DataChunkHandler{failOnBad, failOnBad}
+
+				return ProcessSharded(strings.NewReader(data), 2, keyFn, handlers, DelimiteByNewLine)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.run()
+
+			if !errors.Is(err, errBadChunk) {
+				t.Fatalf("got error [%v], want [%v]", err, errBadChunk)
+			}
+
+			var chunkErr *ChunkError
+
+			if !errors.As(err, &chunkErr) {
+				t.Fatalf("got error [%v], want a ChunkError", err)
+			}
+
+			if chunkErr.ChunkMeta != wantMeta {
+				t.Errorf("got the chunk error at %+v, want it at %+v", chunkErr.ChunkMeta, wantMeta)
+			}
+		})
+	}
+}
+
+func TestProcessDataSourceInChunksConcurrent(t *testing.T) {
+	const records = 500
+
+	var lines []string
+
+	for i := 0; i < records; i++ {
+		lines = append(lines, fmt.Sprintf("record %03d", i))
+	}
+
+	data := strings.Join(lines, "\n")
+
+	for _, workers := range []int{0, 1, 2, 8} {
+		t.Run(fmt.Sprintf("every chunk once with [%d] workers", workers), func(t *testing.T) {
+			var mu sync.Mutex
+			var got []string
+
+			err := ProcessDataSourceInChunksConcurrent(strings.NewReader(data), 7, workers, func(b []byte) error {
+				mu.Lock()
+				defer mu.Unlock()
+
+				got = append(got, string(b))
+
+				return nil
+			}, DelimiteByNewLine)
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			// the chunks are handled in no particular order, sorting them tells whether they were all handled intact.
+			sort.Strings(got)
+
+			if !reflect.DeepEqual(got, lines) {
+				t.Errorf("got [%d] chunks %q, want every one of the [%d] records once", len(got), got, records)
+			}
+		})
+	}
+
+	tests := []struct {
+		name    string
+		failing error
+		wantErr error
+	}{
+		{"handler error", errBadChunk, errBadChunk},
+		{"stopped by the handler", ErrStopProcessing, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			handled := 0
+
+			err := ProcessDataSourceInChunksConcurrent(strings.NewReader(data), 7, 4, func(b []byte) error {
+				mu.Lock()
+				defer mu.Unlock()
+
+				handled++
+
+				if string(b) == lines[10] {
+					return tt.failing
+				}
+
+				return nil
+			}, DelimiteByNewLine)
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error [%v], want [%v]", err, tt.wantErr)
+			}
+
+			// only the chunks already handed to the workers are handled once the processing is stopped.
+			if handled >= records {
+				t.Errorf("[%d] chunks handled, want the processing to stop before the last one", handled)
+			}
+		})
+	}
+
+	t.Run("data source error", func(t *testing.T) {
+		source := io.MultiReader(strings.NewReader("a\nb\n"), &flakyReader{failures: 1})
+
+		err := ProcessDataSourceInChunksConcurrent(source, 7, 4, func([]byte) error { return nil }, DelimiteByNewLine)
+
+		if !errors.Is(err, errTransient) {
+			t.Errorf("got error [%v], want [%v]", err, errTransient)
+		}
+	})
+}
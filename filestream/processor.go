@@ -0,0 +1,919 @@
+package filestream
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"mime/quotedprintable"
+	"time"
+	"unicode/utf8"
+)
+
+// Option, configures a Processor built by NewProcessor.
+type Option func(*Processor)
+
+// Logger, where a Processor writes its diagnostic messages, which *log.Logger already is, any structured logger can be
+// adapted to it with a single method.
+type Logger interface {
+	Printf(format string, args ...interface{})
+}
+
+// noopLogger, the Logger of a Processor built without WithLogger, which discards every message.
+type noopLogger struct{}
+
+func (noopLogger) Printf(string, ...interface{}) {}
+
+// Processor, processes a data source in chunks just as ProcessDataSourceInChunks does, configured by options instead of
+// positional arguments. Without any option the data source is read defaultChunkSize bytes at a time and delimited by
+// DelimiteByNewLine, with no limit for the size of a chunk and no context.
+type Processor struct {
+	dataSource      io.Reader
+	chunkSize       int
+	maxChunkSize    int
+	chunkDelimiter  DataChunkDelimiter
+	ctx             context.Context
+	progress        chan<- Progress
+	explode         func([]byte) ([][]byte, error)
+	fullReads       bool
+	onRead          func(int64)
+	strict          bool
+	join            func(prev, cur []byte) bool
+	merge           func(prev, cur []byte) []byte
+	hash            hash.Hash
+	fallback        io.Writer
+	wholeRunes      bool
+	skipEmpty       bool
+	readAttempts    int
+	readRetryable   func(error) bool
+	readBackoff     func(int) time.Duration
+	byteLimit       int64
+	skipLines       int
+	skipPrefix      func(line []byte) bool
+	budget          *HandlerBudget
+	windowSize      int
+	onWindow        func(window [][]byte)
+	bytesPerSecond  int
+	logger          Logger
+	onComplete      func() error
+	headerBytes     int64
+	decompressor    DecompressorFactory
+	rawTee          io.Writer
+	teeDecompressed bool
+	onDelimiterCall func(time.Duration)
+	utf8Policy      UTF8Policy
+	decodeQP        bool
+
+	// chunkHeader, the header values of the current chunk, as parsed by the delimiter of WithRecordDelimiter, and
+	// record, the position and header of the record being handed over by RunRecords.
+	chunkHeader map[string]interface{}
+	record      recordMeta
+
+	// scanner, the ChunkScanner of the processing being run, so the runs built on top of Run can tell where the current
+	// chunk was found.
+	scanner *ChunkScanner
+
+	chunkDelimiterWithError     DataChunkDelimiterWithError
+	chunkDelimiterWithLookahead DataChunkDelimiterWithLookahead
+	chunkDelimiterWithEOF       DataChunkDelimiterWithEOF
+}
+
+// NewProcessor, builds a Processor for the data source, configured by the given options.
+func NewProcessor(dataSource io.Reader, opts ...Option) *Processor {
+	p := &Processor{
+		dataSource:     dataSource,
+		chunkSize:      defaultChunkSize,
+		chunkDelimiter: DelimiteByNewLine,
+		ctx:            context.Background(),
+		logger:         noopLogger{},
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
+}
+
+// WithChunkSize, sets how many bytes are asked for in each read of the data source.
+func WithChunkSize(chunkSize int) Option {
+	return func(p *Processor) {
+		p.chunkSize = chunkSize
+	}
+}
+
+// WithDelimiter, sets the DataChunkDelimiter the data source is delimited by.
+func WithDelimiter(chunkDelimiter DataChunkDelimiter) Option {
+	return func(p *Processor) {
+		p.chunkDelimiter = chunkDelimiter
+		p.chunkDelimiterWithError = nil
+		p.chunkDelimiterWithLookahead = nil
+		p.chunkDelimiterWithEOF = nil
+	}
+}
+
+// WithDelimiterWithError, same as WithDelimiter but for a DataChunkDelimiterWithError, whose errors stop the processing.
+func WithDelimiterWithError(chunkDelimiter DataChunkDelimiterWithError) Option {
+	return func(p *Processor) {
+		p.chunkDelimiter = nil
+		p.chunkDelimiterWithError = chunkDelimiter
+		p.chunkDelimiterWithLookahead = nil
+		p.chunkDelimiterWithEOF = nil
+	}
+}
+
+// WithDelimiterWithLookahead, same as WithDelimiter but for a DataChunkDelimiterWithLookahead, which is only called
+// again once the data it asked for arrived.
+func WithDelimiterWithLookahead(chunkDelimiter DataChunkDelimiterWithLookahead) Option {
+	return func(p *Processor) {
+		p.chunkDelimiter = nil
+		p.chunkDelimiterWithError = nil
+		p.chunkDelimiterWithLookahead = chunkDelimiter
+		p.chunkDelimiterWithEOF = nil
+	}
+}
+
+// WithDelimiterWithEOF, same as WithDelimiter but for a DataChunkDelimiterWithEOF, which is called once more when the
+// data source is over to decide what the data left is.
+func WithDelimiterWithEOF(chunkDelimiter DataChunkDelimiterWithEOF) Option {
+	return func(p *Processor) {
+		p.chunkDelimiter = nil
+		p.chunkDelimiterWithError = nil
+		p.chunkDelimiterWithLookahead = nil
+		p.chunkDelimiterWithEOF = chunkDelimiter
+	}
+}
+
+// WithMaxChunkSize, sets how large the data collected while looking for the end of a chunk is allowed to grow before
+// the processing stops with an ErrChunkTooLarge, zero means there is no limit, just as in
+// ProcessDataSourceInChunksBounded.
+func WithMaxChunkSize(maxChunkSize int) Option {
+	return func(p *Processor) {
+		p.maxChunkSize = maxChunkSize
+	}
+}
+
+// WithContext, sets the context that aborts the processing once it is done, just as in
+// ProcessDataSourceInChunksContext.
+func WithContext(ctx context.Context) Option {
+	return func(p *Processor) {
+		p.ctx = ctx
+	}
+}
+
+// WithProgressChannel, sets a channel the Progress of the processing is sent to as chunks are handled, a consumer slower
+// than the processing never blocks it, the updates it did not receive in time are dropped in favor of the latest one.
+// The final Progress is always sent, once the processing is over, and the channel is closed right after it.
+// NOTE: the final Progress is sent by another goroutine, so Run may return before the consumer receives it.
+func WithProgressChannel(progress chan<- Progress) Option {
+	return func(p *Processor) {
+		p.progress = progress
+	}
+}
+
+// WithExplode, sets a function that splits every chunk into many records, such as the elements of a JSON array line,
+// each record returned is handed to the handler separately, in order, instead of the chunk itself. A chunk turned into
+// no record at all is dropped, while an error from the function stops the processing.
+func WithExplode(explode func(chunk []byte) ([][]byte, error)) Option {
+	return func(p *Processor) {
+		p.explode = explode
+	}
+}
+
+// WithFullReads, sets whether every read of the data source must fill the whole chunk size before the data is
+// delimited, as io.ReadFull does, instead of delimiting whatever a single read returned, which evens out the work of
+// data sources returning tiny reads. Only the last read, cut by the end of the data source, may fill less.
+// NOTE: a read only returns once the whole chunk size arrived, so it is not meant for interactive data sources, such
+// as connections, where data does not keep flowing.
+func WithFullReads(fullReads bool) Option {
+	return func(p *Processor) {
+		p.fullReads = fullReads
+	}
+}
+
+// WithProgress, sets a function called after every read of the data source that returned data, with the total number
+// of bytes read so far, such as for a progress bar. It is called in the goroutine reading the data source, so it should
+// return quickly.
+func WithProgress(onProgress func(bytesRead int64)) Option {
+	return func(p *Processor) {
+		p.onRead = onProgress
+	}
+}
+
+// WithProgressOf, same as WithProgress but for a data source whose size is known in advance, such as a file, the
+// function also receives the percentage of the data source read so far.
+func WithProgressOf(totalSize int64, onProgress func(bytesRead int64, percent float64)) Option {
+	return WithProgress(func(bytesRead int64) {
+		percent := 100.0
+
+		if totalSize > 0 {
+			percent = float64(bytesRead) * 100 / float64(totalSize)
+		}
+
+		onProgress(bytesRead, percent)
+	})
+}
+
+// WithStrictTermination, sets whether the data source must end right after the terminator of its last chunk, for
+// formats that must be perfectly delimited, the data left after it, which is otherwise handed over as the last chunk,
+// stops the processing with an ErrTrailingData instead. A single new line after the last chunk is still accepted.
+func WithStrictTermination(strict bool) Option {
+	return func(p *Processor) {
+		p.strict = strict
+	}
+}
+
+// WithJoin, sets a predicate and a merge function for records that belong to the one before them, such as the
+// indented stack frames following a log line, every chunk the predicate matches is merged into the previous record,
+// instead of being handed over on its own, and the handler only receives a record once the chunk after it does not
+// belong to it, or the data source is over. The first argument of both functions is the record merged so far and the
+// second one is the current chunk.
+// NOTE: the merged record is held across chunks, so it is copied, which makes the functions free to keep or return
+// either argument.
+func WithJoin(join func(prev, cur []byte) bool, merge func(prev, cur []byte) []byte) Option {
+	return func(p *Processor) {
+		p.join = join
+		p.merge = merge
+	}
+}
+
+// WithHash, sets a hash every byte read from the data source is written to, delimiters included, regardless of how it
+// is delimited, so the integrity of the data source can be checked by calling Sum once Run returns, without reading it
+// a second time.
+// NOTE: a processing stopped early, by an error or by ErrStopProcessing, only hashes the bytes read up to that point.
+func WithHash(h hash.Hash) Option {
+	return func(p *Processor) {
+		p.hash = h
+	}
+}
+
+// WithFallbackOnError, sets a writer the data not processed yet is copied to whenever the processing fails, such as to
+// keep it for being processed again later, which is the data read but left over after the last chunk found, followed by
+// the rest of the data source. For an error returned by the handler that is everything after the chunk it failed at,
+// the chunk itself is not copied since it may have lost its delimiter, for any other error the data already collected
+// while looking for the end of a chunk is lost. A processing stopped by its context is not copied at all.
+// NOTE: the error of the processing is still returned, a failed copy is only told by the error message.
+func WithFallbackOnError(fallback io.Writer) Option {
+	return func(p *Processor) {
+		p.fallback = fallback
+	}
+}
+
+// WithRuneBoundaries, sets whether chunks must never end in the middle of a UTF-8 encoded character, for text handlers
+// along with delimiters that may cut one when splitting the data, such as DelimiteByFixedSize, the bytes of a character
+// cut at the end of a chunk are moved to the beginning of the next one instead. A chunk made only of the beginning of
+// a character is still handed over as it is, since it could never grow otherwise, and so is the end of the data source.
+// NOTE: chunks may end up shorter than the delimiter meant them to be, so it suits delimiters splitting text, not
+// binary records.
+func WithRuneBoundaries(wholeRunes bool) Option {
+	return func(p *Processor) {
+		p.wholeRunes = wholeRunes
+	}
+}
+
+// WithSkipEmpty, sets whether the empty chunks are dropped instead of handed over, such as the ones for blank lines or
+// for consecutive delimiters, so a data source with nothing but delimiters has no chunk at all.
+func WithSkipEmpty(skipEmpty bool) Option {
+	return func(p *Processor) {
+		p.skipEmpty = skipEmpty
+	}
+}
+
+// WithReadRetry, sets how many times in total a read of the data source failing with an error for which isRetryable
+// returns "true" is attempted, waiting for backoff(attempt) between attempts, just as NewRetryReader does, any other
+// error still stops the processing right away. A nil isRetryable retries the temporary errors, the ones with a
+// Temporary method returning "true" such as the timeouts of many network errors, and a nil backoff does not wait.
+func WithReadRetry(maxAttempts int, isRetryable func(error) bool, backoff func(int) time.Duration) Option {
+	return func(p *Processor) {
+		p.readAttempts = maxAttempts
+		p.readRetryable = isRetryable
+		p.readBackoff = backoff
+	}
+}
+
+// WithByteLimit, sets how many bytes at most are read from the data source, such as to sample the beginning of a huge
+// file, once they are read the processing goes on as if the data source was over, so the data after the last
+// delimiter found within the limit, cut in the middle more often than not, is handed over as the last chunk. Along
+// with WithStrictTermination that cut chunk stops the processing with an ErrTrailingData instead. Zero, or less, means
+// there is no limit.
+func WithByteLimit(byteLimit int64) Option {
+	return func(p *Processor) {
+		p.byteLimit = byteLimit
+	}
+}
+
+// WithSkipLines, sets how many chunks at the beginning of the data source are dropped without being handed over, such
+// as the header line of a CSV file, just as SkipChunks does, whatever the delimiter is. A data source with fewer chunks
+// than that never calls the handler.
+func WithSkipLines(n int) Option {
+	return func(p *Processor) {
+		p.skipLines = n
+	}
+}
+
+// WithSkipPrefixLines, sets the predicate the chunks at the beginning of the data source are dropped for, without being
+// handed over, for as long as it returns "true", such as a "#!" shebang line followed by "# comment" lines, just as
+// SkipLeadingChunks does. It is checked on the chunks left after the ones dropped by WithSkipLines.
+func WithSkipPrefixLines(predicate func(line []byte) bool) Option {
+	return func(p *Processor) {
+		p.skipPrefix = predicate
+	}
+}
+
+// WithReverseWindow, sets a function called after every record is handed to the handler with the last n records
+// handled, newest first, so the latest record is always the first one, and fewer than n of them while the processing
+// has not handled that many yet.
+// NOTE: the records of the window are reused as the window slides, they are only valid until the function returns and
+// must be copied to be kept any longer.
+func WithReverseWindow(n int, onWindow func(window [][]byte)) Option {
+	return func(p *Processor) {
+		p.windowSize = n
+		p.onWindow = onWindow
+	}
+}
+
+// WithRateLimit, sets how many bytes per second, on average, are read from the data source at most, such as to leave
+// the throughput of a shared disk to other processes, the reads are throttled by waiting after them, which stops as
+// soon as the context is done. Zero, or less, means there is no limit.
+func WithRateLimit(bytesPerSecond int) Option {
+	return func(p *Processor) {
+		p.bytesPerSecond = bytesPerSecond
+	}
+}
+
+// WithLogger, sets the Logger the diagnostic messages of the processing are written to, such as an invalid chunk size,
+// the read errors retried by WithReadRetry and the error that stopped the processing, without it nothing is
+// logged at all.
+func WithLogger(logger Logger) Option {
+	return func(p *Processor) {
+		p.logger = logger
+	}
+}
+
+// WithOnComplete, sets a function called exactly once after the last chunk was handled, such as to flush the last,
+// partial, batch of a handler built by BatchHandler, its error is the one returned by the processing. It is called for
+// a processing stopped by ErrStopProcessing too, since nothing failed, but it is not called at all when the processing
+// fails, handlers keeping state must then be finalized by the caller, if needed.
+func WithOnComplete(onComplete func() error) Option {
+	return func(p *Processor) {
+		p.onComplete = onComplete
+	}
+}
+
+// WithSkipHeaderBytes, sets how many bytes at the beginning of the data source are discarded before any chunk is looked
+// for, such as the fixed size header of a binary format preceding its records. The bytes skipped still count as read,
+// so the offsets of the chunks, as in ChunkMeta and Record, are the ones in the whole data source, header included, and
+// they are hashed and throttled just as the rest of it. A data source shorter than the header stops the processing
+// with an io.ErrUnexpectedEOF before any chunk is handled.
+func WithSkipHeaderBytes(n int) Option {
+	return func(p *Processor) {
+		p.headerBytes = int64(n)
+	}
+}
+
+// WithDecompressor, sets the DecompressorFactory the data source is wrapped with before it is read, such as
+// NewGzipSource, so the chunks are delimited in its decompressed content, an error from the factory, such as an invalid
+// header, is returned by Run before any chunk is handled. The reads are retried, with WithReadRetry, on the data source
+// itself, while everything else, the byte limit, the hash and the offsets included, is about the decompressed content.
+// NOTE: a read blocked in the data source is only interrupted by the context once the decompressor returns from it.
+func WithDecompressor(factory DecompressorFactory) Option {
+	return func(p *Processor) {
+		p.decompressor = factory
+	}
+}
+
+// WithRawTee, sets a writer every byte read from the data source is copied to, exactly as it was read, before it is
+// decompressed by the factory given to WithDecompressor, such as to keep the very bytes processed for auditing. The
+// bytes copied to the fallback of WithFallbackOnError are read from the data source too, so they are written as well.
+// NOTE: a processing stopped early, by an error or by ErrStopProcessing, only copies the bytes read up to that point,
+// which may be past the last chunk handled.
+func WithRawTee(w io.Writer) Option {
+	return func(p *Processor) {
+		p.rawTee = w
+	}
+}
+
+// WithTeeAfterDecompression, sets whether the writer of WithRawTee receives the decompressed content of the data
+// source instead of the bytes read from it, which makes no difference without WithDecompressor.
+func WithTeeAfterDecompression(afterDecompression bool) Option {
+	return func(p *Processor) {
+		p.teeDecompressed = afterDecompression
+	}
+}
+
+// WithDelimiterTiming, sets a function called after every call to the delimiter with the time it took, which helps
+// telling whether a custom delimiter is the bottleneck of the processing, just as TimeDelimiter does for any kind of
+// delimiter. Keep in mind the delimiter is called again on all the data collected so far after every read, so the
+// number of calls depends on the chunk size.
+// NOTE: DelimiteByNewLine and DelimiteByLine are otherwise never called, their lines are found by a faster search of
+// the data read, which is given up while the delimiter is timed.
+func WithDelimiterTiming(onCall func(d time.Duration)) Option {
+	return func(p *Processor) {
+		p.onDelimiterCall = onCall
+	}
+}
+
+// WithUTF8Policy, sets the UTF8Policy applied to every chunk that is not valid UTF-8, just as ApplyUTF8Policy does,
+// before it is joined to others or exploded, so a rejected chunk stops the processing with an ErrInvalidUTF8 at the
+// chunk itself. The chunks dropped by WithSkipLines are not validated at all.
+func WithUTF8Policy(policy UTF8Policy) Option {
+	return func(p *Processor) {
+		p.utf8Policy = policy
+	}
+}
+
+// WithQuotedPrintableDecode, sets whether every chunk is decoded from quoted-printable, such as the lines of an email
+// body, right after it is delimited, so every "=XX" sequence is turned into the byte it stands for and the lines ending
+// with a soft line break ("=") are joined to the ones after them, in a single chunk, just as NewQuotedPrintableSource
+// does for the whole data source. An invalid "=" sequence is kept as it is, while a byte that is never written in
+// quoted-printable, such as a NUL, stops the processing with the error of the decoding.
+// NOTE: it is meant for the line delimiters, the soft line breaks of chunks delimited by anything else are not joined
+// across the delimiters they were split at.
+func WithQuotedPrintableDecode() Option {
+	return func(p *Processor) {
+		p.decodeQP = true
+	}
+}
+
+// Run, processes the whole data source handing every chunk, in order, to the handler.
+func (p *Processor) Run(chunkHandler DataChunkHandler) error {
+	return p.run(chunkHandler, false)
+}
+
+// run, same as Run but along with records it also keeps the position and header of the record being handed over up to
+// date, for RunRecords.
+func (p *Processor) run(chunkHandler DataChunkHandler, records bool) error {
+	dataSource := p.dataSource
+
+	// the scanner reads a single byte at a time for any chunk size smaller than one, which still works, only slowly.
+	if p.chunkSize < 1 {
+		p.logger.Printf("invalid chunk size [%d], the data source is read one byte at a time instead", p.chunkSize)
+	}
+
+	if p.readAttempts > 1 {
+		retryable := p.readRetryable
+
+		if retryable == nil {
+			retryable = isTemporary
+		}
+
+		isRetryable := func(err error) bool {
+			if !retryable(err) {
+				return false
+			}
+
+			p.logger.Printf("retryable error reading the data source: [%v]", err)
+
+			return true
+		}
+
+		dataSource = NewRetryReader(dataSource, p.readAttempts, isRetryable, p.readBackoff)
+	}
+
+	// the tee gets the bytes exactly as they are read, unless it asked for the decompressed ones.
+	if p.rawTee != nil && (p.decompressor == nil || !p.teeDecompressed) {
+		dataSource = io.TeeReader(dataSource, p.rawTee)
+	}
+
+	if p.decompressor != nil {
+		decompressed, err := p.decompressor(dataSource)
+
+		if err != nil {
+			p.logger.Printf("decompressing the data source failed: [%v]", err)
+			return err
+		}
+
+		if closer, ok := decompressed.(io.Closer); ok {
+			defer closer.Close()
+		}
+
+		dataSource = decompressed
+
+		if p.rawTee != nil && p.teeDecompressed {
+			dataSource = io.TeeReader(dataSource, p.rawTee)
+		}
+	}
+
+	// the data not processed is copied to the fallback from here, since the readers wrapping it hold no data.
+	source := dataSource
+
+	if p.byteLimit > 0 {
+		dataSource = io.LimitReader(dataSource, p.byteLimit)
+	}
+
+	if p.hash != nil {
+		dataSource = io.TeeReader(dataSource, p.hash)
+	}
+
+	if p.fullReads {
+		dataSource = fullReader{src: dataSource}
+	}
+
+	if p.onRead != nil {
+		dataSource = &progressReader{src: dataSource, onRead: p.onRead}
+	}
+
+	// a context that can never be done has nothing to interrupt.
+	if p.ctx.Done() != nil {
+		dataSource = newContextReader(p.ctx, dataSource)
+	}
+
+	// the throttling wraps the context reader, which needs to see the read deadlines of the data source itself.
+	if p.bytesPerSecond > 0 {
+		dataSource = newRateLimitedReader(p.ctx, dataSource, p.bytesPerSecond)
+	}
+
+	// the window slides over the records actually handed to the handler, the ones exploded from chunks included.
+	if p.onWindow != nil && p.windowSize > 0 {
+		ring := make([][]byte, p.windowSize)
+		window := make([][]byte, 0, p.windowSize)
+		handled := 0
+		handler := chunkHandler
+
+		chunkHandler = func(b []byte) error {
+			if err := handler(b); err != nil {
+				return err
+			}
+
+			newest := handled % len(ring)
+			ring[newest] = append(ring[newest][:0], b...)
+			handled++
+
+			window = window[:0]
+
+			for i := 0; i < len(ring) && i < handled; i++ {
+				window = append(window, ring[(newest-i+len(ring))%len(ring)])
+			}
+
+			p.onWindow(window)
+
+			return nil
+		}
+	}
+
+	if p.explode != nil {
+		recordHandler := chunkHandler
+
+		chunkHandler = func(b []byte) error {
+			records, err := p.explode(b)
+
+			if err != nil {
+				return err
+			}
+
+			for _, record := range records {
+				if err = recordHandler(record); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		}
+	}
+
+	var flushJoined func() error
+
+	if p.join != nil {
+		var pending []byte
+		var hasPending, stopped bool
+		handler := chunkHandler
+
+		// the record joined is handed over with the position and header of its first chunk.
+		var pendingRecord recordMeta
+
+		chunkHandler = func(b []byte) error {
+			if hasPending && p.join(pending, b) {
+				pending = append([]byte(nil), p.merge(pending, b)...)
+				return nil
+			}
+
+			current := p.record
+
+			if hasPending {
+				p.record = pendingRecord
+
+				if err := handler(pending); err != nil {
+					stopped = true
+					return err
+				}
+			}
+
+			pending = append(pending[:0:0], b...)
+			pendingRecord = current
+			hasPending = true
+
+			return nil
+		}
+
+		flushJoined = func() error {
+			if !hasPending || stopped {
+				return nil
+			}
+
+			hasPending = false
+			p.record = pendingRecord
+
+			return handler(pending)
+		}
+	}
+
+	if p.progress != nil {
+		var current Progress
+		reporter := newProgressReporter(p.progress)
+		handler := chunkHandler
+
+		dataSource = countingReader{src: dataSource, count: &current.BytesRead}
+
+		chunkHandler = func(b []byte) error {
+			if err := handler(b); err != nil {
+				return err
+			}
+
+			current.ChunksProcessed++
+			reporter.report(current)
+
+			return nil
+		}
+
+		defer func() {
+			reporter.finish(current)
+		}()
+	}
+
+	var flushDecoded func() error
+
+	// the chunks are validated once decoded, since the bytes they are encoded in are always valid.
+	if p.decodeQP {
+		chunkHandler, flushDecoded = decodeQuotedPrintableLines(chunkHandler)
+	}
+
+	if p.utf8Policy != UTF8PolicyIgnore {
+		chunkHandler = ApplyUTF8Policy(p.utf8Policy, chunkHandler)
+	}
+
+	if p.skipEmpty {
+		handler := chunkHandler
+
+		chunkHandler = func(b []byte) error {
+			if len(b) == 0 {
+				return nil
+			}
+
+			return handler(b)
+		}
+	}
+
+	if p.skipPrefix != nil {
+		chunkHandler = SkipLeadingChunks(p.skipPrefix, chunkHandler)
+	}
+
+	// the chunks skipped are the first ones delimited, before any of them is dropped for being empty.
+	if p.skipLines > 0 {
+		chunkHandler = SkipChunks(p.skipLines, chunkHandler)
+	}
+
+	// every chunk starts a record of its own, the joined ones only change it when they are handed over.
+	if records {
+		handler := chunkHandler
+
+		chunkHandler = func(b []byte) error {
+			p.record = recordMeta{offset: p.scanner.chunkOffset, header: p.chunkHeader}
+			return handler(b)
+		}
+	}
+
+	var skipped int64
+
+	if p.headerBytes > 0 {
+		var err error
+
+		if skipped, err = io.CopyN(io.Discard, dataSource, p.headerBytes); err == io.EOF {
+			err = fmt.Errorf(
+				"%w: the data source ended after [%d] of the [%d] header bytes",
+				io.ErrUnexpectedEOF,
+				skipped,
+				p.headerBytes)
+		}
+
+		if err != nil {
+			p.logger.Printf("skipping the header failed: [%v]", err)
+			return err
+		}
+	}
+
+	var scanner *ChunkScanner
+
+	if p.chunkDelimiterWithEOF != nil {
+		scanner = newChunkScannerWithEOF(p.ctx, dataSource, p.chunkSize, p.maxChunkSize, p.chunkDelimiterWithEOF)
+	} else if p.chunkDelimiterWithLookahead != nil {
+		scanner = newChunkScannerWithLookahead(
+			p.ctx, dataSource, p.chunkSize, p.maxChunkSize, p.chunkDelimiterWithLookahead)
+	} else if p.chunkDelimiterWithError != nil {
+		scanner = newChunkScannerWithError(p.ctx, dataSource, p.chunkSize, p.maxChunkSize, p.chunkDelimiterWithError)
+	} else {
+		scanner = newChunkScanner(p.ctx, dataSource, p.chunkSize, p.maxChunkSize, p.chunkDelimiter)
+	}
+
+	scanner.strictTermination = p.strict
+	scanner.bytesRead = skipped
+	scanner.consumed = skipped
+	p.scanner = scanner
+
+	// the time taken by the delimiter alone, not by the rune boundaries kept by the wrapping below.
+	if p.onDelimiterCall != nil {
+		scanner.timeDelimiter(p.onDelimiterCall)
+	}
+
+	// the line delimiters never cut a character, since they only split the data at new lines.
+	if p.wholeRunes {
+		scanner.chunkDelimiter = keepRunesWhole(scanner.chunkDelimiter)
+	}
+
+	_, err := processChunks(scanner, chunkHandler, nil)
+
+	// the last record is only known to be complete once the data source is over, the last line decoded goes first,
+	// since it may still be joined to the record before it.
+	for _, flush := range []func() error{flushDecoded, flushJoined} {
+		if err != nil || flush == nil {
+			continue
+		}
+
+		if err = flush(); errors.Is(err, ErrStopProcessing) {
+			err = nil
+			break
+		}
+
+		if err != nil {
+			err = &ChunkError{ChunkMeta: ChunkMeta{Offset: scanner.chunkOffset, Index: scanner.chunkIndex}, Err: err}
+		}
+	}
+
+	if err == nil && p.onComplete != nil {
+		err = p.onComplete()
+	}
+
+	if err != nil {
+		p.logger.Printf("processing stopped at chunk [%d]: [%v]", scanner.chunkIndex, err)
+	}
+
+	if err != nil && p.fallback != nil && p.ctx.Err() == nil {
+		err = p.copyToFallback(scanner, source, err)
+	}
+
+	return err
+}
+
+// Remaining, returns the bytes already read from the data source that were not part of any chunk handed over when the
+// last Run stopped, such as by an ErrStopProcessing, by its context being done in between chunks or by an error of the
+// handler, in which case they are the ones after the chunk it failed at. Along with the rest of the data source they
+// let the processing be resumed later from where it stopped, which is only meaningful for data sources that can go on
+// being read, nil is returned before any Run.
+// NOTE: an error found while collecting a chunk, such as a failed read, loses the data already collected for it, only
+// what the delimiter had not looked at yet is returned then.
+func (p *Processor) Remaining() []byte {
+	if p.scanner == nil {
+		return nil
+	}
+
+	return p.scanner.remaining()
+}
+
+// copyToFallback, copies the data not processed by the scanner to the fallback writer, along with the rest of the data
+// source, decompressed when it is, which is read directly since none of the readers wrapping it holds any data, and
+// returns the error of the processing, telling about the copy in it when it failed too.
+func (p *Processor) copyToFallback(scanner *ChunkScanner, source io.Reader, err error) error {
+	_, copyErr := p.fallback.Write(scanner.remaining())
+
+	if copyErr == nil {
+		_, copyErr = io.Copy(p.fallback, source)
+	}
+
+	if copyErr != nil {
+		return fmt.Errorf("%w, copying the data not processed to the fallback failed too: [%v]", err, copyErr)
+	}
+
+	return err
+}
+
+// keepRunesWhole, wraps the delimiter of a scanner moving the bytes of a UTF-8 encoded character cut at the end of every
+// chunk it finds to the beginning of its left over.
+func keepRunesWhole(chunkDelimiter scannerDelimiter) scannerDelimiter {
+	return func(data []byte, atEOF bool) (bool, []byte, []byte, int, error) {
+		enough, chunk, leftOver, lookahead, err := chunkDelimiter(data, atEOF)
+
+		if !enough || err != nil {
+			return enough, chunk, leftOver, lookahead, err
+		}
+
+		cut := len(chunk)
+
+		// a character is at most utf8.UTFMax bytes long, so only the last bytes can start one that was cut.
+		for i := len(chunk) - 1; i >= 0 && i >= len(chunk)-utf8.UTFMax+1; i-- {
+			if utf8.RuneStart(chunk[i]) {
+				if !utf8.FullRune(chunk[i:]) {
+					cut = i
+				}
+
+				break
+			}
+		}
+
+		if cut == len(chunk) || cut == 0 {
+			return enough, chunk, leftOver, lookahead, err
+		}
+
+		// the chunk and the left over may share the same memory, so both parts are copied into a new left over.
+		movedLeftOver := make([]byte, 0, len(chunk)-cut+len(leftOver))
+		movedLeftOver = append(movedLeftOver, chunk[cut:]...)
+		movedLeftOver = append(movedLeftOver, leftOver...)
+
+		return enough, chunk[:cut], movedLeftOver, lookahead, err
+	}
+}
+
+// decodeQuotedPrintableLines, wraps the handler decoding every chunk from quoted-printable before handing it over, the
+// chunks ending with a soft line break are held until the one ending the line they split, the function returned hands
+// over the last one held, when the data source ends with a soft line break.
+func decodeQuotedPrintableLines(chunkHandler DataChunkHandler) (DataChunkHandler, func() error) {
+	var pending []byte
+	var stopped bool
+
+	decodeLine := func() error {
+		decoded, err := io.ReadAll(quotedprintable.NewReader(bytes.NewReader(pending)))
+		pending = pending[:0]
+
+		if err == nil {
+			err = chunkHandler(decoded)
+		}
+
+		stopped = err != nil
+
+		return err
+	}
+
+	handler := func(b []byte) error {
+		pending = append(pending, b...)
+
+		// the soft line break, which may be followed by white space, is decoded along with the line after it.
+		if trimmed := bytes.TrimRight(b, " \t\r"); len(trimmed) > 0 && trimmed[len(trimmed)-1] == '=' {
+			pending = append(pending, newLineByte)
+			return nil
+		}
+
+		return decodeLine()
+	}
+
+	flush := func() error {
+		if len(pending) == 0 || stopped {
+			return nil
+		}
+
+		return decodeLine()
+	}
+
+	return handler, flush
+}
+
+// isTemporary, tells whether the error, or any error it wraps, reports itself as temporary.
+func isTemporary(err error) bool {
+	var temporary interface{ Temporary() bool }
+
+	return errors.As(err, &temporary) && temporary.Temporary()
+}
+
+// fullReader, an io.Reader that fills the whole buffer given in every read, unless the underlying reader ends first, in
+// which case the bytes read are returned along with an io.EOF.
+type fullReader struct {
+	src io.Reader
+}
+
+func (r fullReader) Read(p []byte) (int, error) {
+	n, err := io.ReadFull(r.src, p)
+
+	// a partial fill is the end of the data source, not an error.
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+
+	return n, err
+}
+
+// progressReader, an io.Reader that reports the total number of bytes read from the underlying one after every read.
+type progressReader struct {
+	src       io.Reader
+	bytesRead int64
+	onRead    func(int64)
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.src.Read(p)
+
+	if n > 0 {
+		r.bytesRead += int64(n)
+		r.onRead(r.bytesRead)
+	}
+
+	return n, err
+}
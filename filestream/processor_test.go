@@ -0,0 +1,289 @@
+package filestream
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithSkipHeaderBytes(t *testing.T) {
+	// a 16 bytes header, magic number, version and reserved bytes, followed by records with a single byte length.
+	header := "FMT1\x00\x00\x00\x02reserved"
+	records := "\x03abc\x02de"
+
+	type found struct {
+		Index   int
+		Offset  int64
+		Payload string
+	}
+
+	tests := []struct {
+		name    string
+		data    string
+		want    []found
+		wantErr error
+	}{
+		{
+			"records after the header",
+			header + records,
+			[]found{{Index: 1, Offset: 16, Payload: "abc"}, {Index: 2, Offset: 20, Payload: "de"}},
+			nil,
+		},
+		{"header only", header, []found{}, nil},
+		{"shorter than the header", header[:10], []found{}, io.ErrUnexpectedEOF},
+	}
+
+	for _, tt := range tests {
+		for _, chunkSize := range chunkSizes {
+			t.Run(fmt.Sprintf("%s, chunk size [%d]", tt.name, chunkSize), func(t *testing.T) {
+				got := []found{}
+				p := NewProcessor(
+					strings.NewReader(tt.data),
+					WithChunkSize(chunkSize),
+					WithSkipHeaderBytes(len(header)),
+					WithDelimiterWithError(DelimiteByLengthPrefix(1, binary.BigEndian, 0)))
+
+				err := p.RunRecords(func(record Record) error {
+					got = append(got, found{Index: record.Index, Offset: record.Offset, Payload: string(record.Payload)})
+					return nil
+				})
+
+				if !errors.Is(err, tt.wantErr) || (err != nil) != (tt.wantErr != nil) {
+					t.Fatalf("got error [%v], want [%v]", err, tt.wantErr)
+				}
+
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("got %+v, want %+v", got, tt.want)
+				}
+			})
+		}
+	}
+}
+
+func TestWithRawTee(t *testing.T) {
+	content := "first line\nsecond line\nlast line without new line"
+	compressed := gzipped(t, content)
+
+	tests := []struct {
+		name    string
+		input   []byte
+		opts    []Option
+		wantTee []byte
+	}{
+		{"plain data source", []byte(content), nil, []byte(content)},
+		{"before decompression", compressed, []Option{WithDecompressor(NewGzipSource)}, compressed},
+		{
+			"after decompression",
+			compressed,
+			[]Option{WithDecompressor(NewGzipSource), WithTeeAfterDecompression(true)},
+			[]byte(content),
+		},
+		{
+			"after decompression without decompressor",
+			[]byte(content),
+			[]Option{WithTeeAfterDecompression(true)},
+			[]byte(content),
+		},
+	}
+
+	for _, tt := range tests {
+		for _, chunkSize := range chunkSizes {
+			t.Run(fmt.Sprintf("%s, chunk size [%d]", tt.name, chunkSize), func(t *testing.T) {
+				var tee bytes.Buffer
+				var chunks []string
+
+				opts := append([]Option{WithChunkSize(chunkSize), WithRawTee(&tee)}, tt.opts...)
+				err := NewProcessor(bytes.NewReader(tt.input), opts...).Run(func(chunk []byte) error {
+					chunks = append(chunks, string(chunk))
+					return nil
+				})
+
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+
+				if !bytes.Equal(tee.Bytes(), tt.wantTee) {
+					t.Errorf("teed %q, want %q", tee.Bytes(), tt.wantTee)
+				}
+
+				if want := strings.Split(content, "\n"); !reflect.DeepEqual(chunks, want) {
+					t.Errorf("got %q, want %q", chunks, want)
+				}
+			})
+		}
+	}
+}
+
+func TestWithQuotedPrintableDecode(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		opts    []Option
+		want    []string
+		wantErr bool
+	}{
+		{
+			"encoded paragraph",
+			"Caf=C3=A9 au lait, a line long enough to be wrapped by the encoder, which soft=\n" +
+				"ly breaks it =\n" +
+				"in a few lines.\n" +
+				"One =3D one\n",
+			nil,
+			[]string{
+				"Café au lait, a line long enough to be wrapped by the encoder, which softly breaks it in a few lines.",
+				"One = one",
+			},
+			false,
+		},
+		{
+			"carriage returns",
+			"a=\r\nb\r\nc=3Dd\r\n",
+			[]Option{WithDelimiter(DelimiteByNewLine)},
+			[]string{"ab", "c=d"},
+			false,
+		},
+		{"soft line break at the end", "first\nlast=", nil, []string{"first", "last"}, false},
+		{"joined after decoding", "a\n =41=\nb\nc", []Option{joinIndentedLines()}, []string{"a\n Ab", "c"}, false},
+		{"invalid sequence kept", "in=ZZvalid\n", nil, []string{"in=ZZvalid"}, false},
+		{"invalid byte", "valid\nin\x00valid\nnever handled\n", nil, []string{"valid"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := collectChunks(t, tt.data, func() []Option {
+				return append([]Option{WithQuotedPrintableDecode()}, tt.opts...)
+			})
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error [%v], want error [%v]", err, tt.wantErr)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// joinIndentedLines, the option joining the lines starting with a space to the record before them.
+func joinIndentedLines() Option {
+	return WithJoin(
+		func(_, cur []byte) bool { return bytes.HasPrefix(cur, []byte(" ")) },
+		func(prev, cur []byte) []byte { return append(append(prev, '\n'), cur...) })
+}
+
+var errTransient = errors.New("transient read error")
+
+// flakyReader, fails the first reads with a transient error before reading the underlying data source.
+type flakyReader struct {
+	src      io.Reader
+	failures int
+}
+
+func (r *flakyReader) Read(p []byte) (int, error) {
+	if r.failures > 0 {
+		r.failures--
+		return 0, errTransient
+	}
+
+	return r.src.Read(p)
+}
+
+func TestWithReadRetry(t *testing.T) {
+	data := "first\nsecond\nthird"
+	isTransient := func(err error) bool { return errors.Is(err, errTransient) }
+
+	tests := []struct {
+		name         string
+		failures     int
+		maxAttempts  int
+		isRetryable  func(error) bool
+		want         []string
+		wantErr      error
+		wantBackoffs []int
+	}{
+		{"no failure", 0, 3, isTransient, []string{"first", "second", "third"}, nil, nil},
+		{"recovers", 2, 3, isTransient, []string{"first", "second", "third"}, nil, []int{1, 2}},
+		{"out of attempts", 3, 3, isTransient, nil, errTransient, []int{1, 2}},
+		{"not retryable", 1, 3, func(error) bool { return false }, nil, errTransient, nil},
+		{"not temporary by default", 1, 3, nil, nil, errTransient, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			var backoffs []int
+
+			backoff := func(attempt int) time.Duration {
+				backoffs = append(backoffs, attempt)
+				return 0
+			}
+
+			source := &flakyReader{src: strings.NewReader(data), failures: tt.failures}
+			err := NewProcessor(source, WithReadRetry(tt.maxAttempts, tt.isRetryable, backoff)).Run(func(chunk []byte) error {
+				got = append(got, string(chunk))
+				return nil
+			})
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error [%v], want [%v]", err, tt.wantErr)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+
+			if !reflect.DeepEqual(backoffs, tt.wantBackoffs) {
+				t.Errorf("backed off after attempts %v, want %v", backoffs, tt.wantBackoffs)
+			}
+		})
+	}
+}
+
+func TestWithSkipPrefixLines(t *testing.T) {
+	isPreamble := func(line []byte) bool { return bytes.HasPrefix(line, []byte("#")) }
+
+	tests := []struct {
+		name string
+		data string
+		opts []Option
+		want []string
+	}{
+		{
+			"shebang and leading comments",
+			"#!/usr/bin/env tool\n# first comment\n# second comment\nfirst\n# kept comment\nlast\n",
+			nil,
+			[]string{"first", "# kept comment", "last"},
+		},
+		{"no preamble", "first\n# kept comment\n", nil, []string{"first", "# kept comment"}},
+		{"only preamble", "#!/usr/bin/env tool\n# comment\n", nil, []string{}},
+		{
+			"after the skipped lines",
+			"header\n# comment\nfirst\n",
+			[]Option{WithSkipLines(1)},
+			[]string{"first"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := collectChunks(t, tt.data, func() []Option {
+				return append([]Option{WithSkipPrefixLines(isPreamble)}, tt.opts...)
+			})
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
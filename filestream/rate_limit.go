@@ -0,0 +1,55 @@
+package filestream
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// rateLimitedReader, an io.Reader that throttles the reads of the underlying one so that, on average since its first
+// read, no more than bytesPerSecond bytes are read per second. Every read asks for at most one second worth of bytes
+// and, once it returns, waits as long as the bytes read so far take at that rate, unless the context is done first, in
+// which case the error of the context is returned along with the bytes read.
+type rateLimitedReader struct {
+	ctx            context.Context
+	src            io.Reader
+	bytesPerSecond int
+	start          time.Time
+	bytesRead      int64
+}
+
+func newRateLimitedReader(ctx context.Context, src io.Reader, bytesPerSecond int) io.Reader {
+	return &rateLimitedReader{ctx: ctx, src: src, bytesPerSecond: bytesPerSecond}
+}
+
+func (r *rateLimitedReader) Read(p []byte) (int, error) {
+	if r.start.IsZero() {
+		r.start = time.Now()
+	}
+
+	if len(p) > r.bytesPerSecond {
+		p = p[:r.bytesPerSecond]
+	}
+
+	n, err := r.src.Read(p)
+	r.bytesRead += int64(n)
+
+	// the duration is computed in floating point since the bytes read times a second in nanoseconds would overflow
+	// an int64 after a few gigabytes.
+	allowedAt := r.start.Add(time.Duration(float64(r.bytesRead) / float64(r.bytesPerSecond) * float64(time.Second)))
+	wait := time.Until(allowedAt)
+
+	if n == 0 || wait <= 0 {
+		return n, err
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return n, err
+	case <-r.ctx.Done():
+		return n, r.ctx.Err()
+	}
+}
@@ -0,0 +1,80 @@
+package filestream
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithRateLimit(t *testing.T) {
+	// 100 lines of 10 bytes, new lines included, a tenth of a second worth of data at the higher rate.
+	data := strings.Repeat("123456789\n", 100)
+
+	tests := []struct {
+		name           string
+		bytesPerSecond int
+		chunkSize      int
+	}{
+		{"small reads", 10000, 16},
+		{"reads larger than the rate", 5000, 1 << 16},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunks := 0
+			start := time.Now()
+
+			err := NewProcessor(
+				strings.NewReader(data),
+				WithChunkSize(tt.chunkSize),
+				WithRateLimit(tt.bytesPerSecond)).Run(func([]byte) error {
+				chunks++
+				return nil
+			})
+
+			elapsed := time.Since(start)
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if chunks != 100 {
+				t.Errorf("[%d] chunks handled, want 100", chunks)
+			}
+
+			// the bytes take exactly that long at the rate given, the upper bound only leaves room for a slow machine.
+			want := time.Duration(float64(len(data)) / float64(tt.bytesPerSecond) * float64(time.Second))
+
+			if elapsed < want || elapsed > want+time.Second {
+				t.Errorf(
+					"[%d] bytes read in [%v] at [%d] bytes per second, want about [%v]",
+					len(data),
+					elapsed,
+					tt.bytesPerSecond,
+					want)
+			}
+		})
+	}
+
+	t.Run("context done while waiting", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+
+		err := NewProcessor(
+			strings.NewReader(data),
+			WithContext(ctx),
+			WithRateLimit(100)).Run(func([]byte) error { return nil })
+
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("got error [%v], want [%v]", err, context.DeadlineExceeded)
+		}
+
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("the processing stopped [%v] after it started, want right after the context was done", elapsed)
+		}
+	})
+}
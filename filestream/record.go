@@ -0,0 +1,101 @@
+package filestream
+
+import (
+	"encoding/binary"
+)
+
+// Record, a record handed over by Processor.RunRecords along with where it was found in the data source and the header
+// values its delimiter parsed out of it, such as the length and type of binary records, with the rest of it as payload.
+type Record struct {
+	// Index, the position of the record among all the records handed over, starting at one, which is the position of
+	// its chunk unless chunks were skipped, joined or exploded into many records.
+	Index int
+	// Offset, where the bytes consumed for the chunk of the record start, as in ChunkMeta, for a record joined from many
+	// chunks it is the offset of the first one.
+	Offset int64
+	// Header, the header values parsed by a RecordDelimiter, nil for any other delimiter, for a record joined from many
+	// chunks it is the header of the first one.
+	Header  map[string]interface{}
+	Payload []byte
+}
+
+// RecordDelimiter, same as DataChunkDelimiterWithError but along with every chunk it delimits it also returns the
+// header values it parsed while delimiting it, which Processor.RunRecords hands over in the Record of the chunk, so the
+// header is neither parsed over again by the handler nor left in the payload.
+type RecordDelimiter func([]byte) (bool, []byte, []byte, map[string]interface{}, error)
+
+// recordMeta, the position and header values of the record being handed over by the handlers built by Run.
+type recordMeta struct {
+	offset int64
+	header map[string]interface{}
+}
+
+// WithRecordDelimiter, same as WithDelimiter but for a RecordDelimiter, whose errors stop the processing and whose
+// header values are handed over by RunRecords.
+func WithRecordDelimiter(chunkDelimiter RecordDelimiter) Option {
+	return func(p *Processor) {
+		WithDelimiterWithError(func(chunk []byte) (bool, []byte, []byte, error) {
+			enough, payload, leftOver, header, err := chunkDelimiter(chunk)
+
+			// the chunk is handed over before the delimiter is called again, so its header is still the one kept.
+			if enough && err == nil {
+				p.chunkHeader = header
+			}
+
+			return enough, payload, leftOver, err
+		})(p)
+	}
+}
+
+// RunRecords, same as Run but every record is handed to the handler as a Record, with where it was found in the data
+// source and the header values of its delimiter, when it was set with WithRecordDelimiter. The position and header of
+// a record are the ones it is handed over with, so they account for WithSkipLines, WithJoin and WithExplode.
+// NOTE: the payload shares the memory of the chunk, so it is only valid until the handler returns, just as the chunk.
+func (p *Processor) RunRecords(recordHandler func(Record) error) error {
+	index := 0
+
+	return p.run(func(b []byte) error {
+		index++
+
+		return recordHandler(Record{Index: index, Offset: p.record.offset, Header: p.record.header, Payload: b})
+	}, true)
+}
+
+// DelimiteByLengthPrefixRecord, same as DelimiteByLengthPrefix but as a RecordDelimiter, whose "length" header is the
+// length declared by the prefix of the payload, as an uint64.
+func DelimiteByLengthPrefixRecord(prefixBytes int, byteOrder binary.ByteOrder, maxLength uint64) RecordDelimiter {
+	chunkDelimiter := DelimiteByLengthPrefix(prefixBytes, byteOrder, maxLength)
+
+	return func(chunk []byte) (bool, []byte, []byte, map[string]interface{}, error) {
+		enough, payload, leftOver, err := chunkDelimiter(chunk)
+
+		if !enough || err != nil {
+			return enough, payload, leftOver, nil, err
+		}
+
+		return true, payload, leftOver, map[string]interface{}{"length": uint64(len(payload))}, nil
+	}
+}
+
+// DelimiteByTLVRecord, same as DelimiteByTLV with valueOnly "true" but as a RecordDelimiter, whose "type" header holds
+// the type byte of the record and whose "length" header the declared length of its value, as an uint64, which is the
+// payload.
+func DelimiteByTLVRecord(lengthBytes int, byteOrder binary.ByteOrder) RecordDelimiter {
+	chunkDelimiter := DelimiteByTLV(lengthBytes, byteOrder, false)
+	headerBytes := tlvTypeBytes + lengthBytes
+
+	return func(chunk []byte) (bool, []byte, []byte, map[string]interface{}, error) {
+		enough, record, leftOver, err := chunkDelimiter(chunk)
+
+		if !enough || err != nil {
+			return enough, record, leftOver, nil, err
+		}
+
+		header := map[string]interface{}{
+			"type":   record[0],
+			"length": readLengthPrefix(record[tlvTypeBytes:headerBytes], byteOrder),
+		}
+
+		return true, record[headerBytes:], leftOver, header, nil
+	}
+}
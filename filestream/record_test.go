@@ -0,0 +1,108 @@
+package filestream
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRunRecords(t *testing.T) {
+	joinIndented := WithJoin(
+		func(_, cur []byte) bool { return bytes.HasPrefix(cur, []byte(" ")) },
+		func(prev, cur []byte) []byte { return append(append(prev, '\n'), cur...) })
+
+	explodeCommas := WithExplode(func(chunk []byte) ([][]byte, error) {
+		return bytes.Split(chunk, []byte(",")), nil
+	})
+
+	tests := []struct {
+		name string
+		data string
+		opts []Option
+		want []Record
+	}{
+		{
+			"length prefix",
+			"\x00\x03abc\x00\x00\x00\x02de",
+			[]Option{WithRecordDelimiter(DelimiteByLengthPrefixRecord(2, binary.BigEndian, 0))},
+			[]Record{
+				{Index: 1, Offset: 0, Header: map[string]interface{}{"length": uint64(3)}, Payload: []byte("abc")},
+				{Index: 2, Offset: 5, Header: map[string]interface{}{"length": uint64(0)}, Payload: []byte{}},
+				{Index: 3, Offset: 7, Header: map[string]interface{}{"length": uint64(2)}, Payload: []byte("de")},
+			},
+		},
+		{
+			"type length value",
+			"\x01\x02ab\x07\x01c",
+			[]Option{WithRecordDelimiter(DelimiteByTLVRecord(1, binary.BigEndian))},
+			[]Record{
+				{
+					Index:   1,
+					Offset:  0,
+					Header:  map[string]interface{}{"type": byte(1), "length": uint64(2)},
+					Payload: []byte("ab"),
+				},
+				{
+					Index:   2,
+					Offset:  4,
+					Header:  map[string]interface{}{"type": byte(7), "length": uint64(1)},
+					Payload: []byte("c"),
+				},
+			},
+		},
+		{
+			"joined records",
+			"a\n b\nc\n d\n e",
+			[]Option{joinIndented},
+			[]Record{
+				{Index: 1, Offset: 0, Payload: []byte("a\n b")},
+				{Index: 2, Offset: 5, Payload: []byte("c\n d\n e")},
+			},
+		},
+		{
+			"exploded records",
+			"1,2\n3",
+			[]Option{explodeCommas},
+			[]Record{
+				{Index: 1, Offset: 0, Payload: []byte("1")},
+				{Index: 2, Offset: 0, Payload: []byte("2")},
+				{Index: 3, Offset: 4, Payload: []byte("3")},
+			},
+		},
+		{
+			"skipped lines",
+			"header\nx\ny",
+			[]Option{WithSkipLines(1)},
+			[]Record{
+				{Index: 1, Offset: 7, Payload: []byte("x")},
+				{Index: 2, Offset: 9, Payload: []byte("y")},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, chunkSize := range chunkSizes {
+				var got []Record
+
+				opts := append([]Option{WithChunkSize(chunkSize)}, tt.opts...)
+				err := NewProcessor(strings.NewReader(tt.data), opts...).RunRecords(func(record Record) error {
+					record.Payload = append([]byte{}, record.Payload...)
+					got = append(got, record)
+
+					return nil
+				})
+
+				if err != nil {
+					t.Fatalf("chunk size [%d]: unexpected error: %v", chunkSize, err)
+				}
+
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("chunk size [%d]: got %+v, want %+v", chunkSize, got, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package filestream
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// ErrNoMatchInWindow, returned by the delimiters built by DelimiteByRegexp with WithScanWindow once no separator ends
+// within the scan window.
+var ErrNoMatchInWindow = errors.New("no match in scan window")
+
+// RegexpOption, configures a delimiter built by DelimiteByRegexp.
+type RegexpOption func(*regexpDelimiterConfig)
+
+type regexpDelimiterConfig struct {
+	scanWindow int
+}
+
+// WithScanWindow, bounds how many bytes the delimiter retains while looking for a separator, as soon as it is known
+// that no separator ends within the first bytes of the data given, the processing stops with an ErrNoMatchInWindow
+// instead of collecting more data, so the memory used for matching stays bounded whatever the data source holds. Zero,
+// the default, means there is no bound.
+func WithScanWindow(bytes int) RegexpOption {
+	return func(c *regexpDelimiterConfig) {
+		c.scanWindow = bytes
+	}
+}
+
+// DelimiteByRegexp, builds a DataChunkDelimiterWithEOF that splits the data at the first match of the regular
+// expression, for separators a fixed sequence of bytes can not express, such as runs of whitespace ("\s+") or "---"
+// lines ("(?m)^---\n"). Everything before the match is the chunk and everything after it is the left over, the match
+// itself is dropped. Matches of no bytes at all are skipped, since they would split the data forever without moving
+// forward. A match reaching the end of the data read so far could still grow with the next read, so it is only used
+// once more data arrives, or once the data source ends, which drops a separator at its very end just as any other.
+// NOTE: the data is matched as it is read, so a pattern whose matches can span any number of bytes, such as "a.*z|q",
+// may find a match read later starting before the one found now, splitting the data differently than it would when
+// matched all at once.
+func DelimiteByRegexp(re *regexp.Regexp, opts ...RegexpOption) DataChunkDelimiterWithEOF {
+	var config regexpDelimiterConfig
+
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	window := config.scanWindow
+
+	return func(chunk []byte, atEOF bool) (bool, []byte, []byte, error) {
+		match := firstNonEmptyMatch(re, chunk)
+
+		// a match touching the end of the data may not be complete yet, unless there is no more data.
+		complete := match != nil && (match[1] < len(chunk) || atEOF)
+
+		// any separator found from now on would end after the window, or the one found already does.
+		if window > 0 && ((complete && match[1] > window) || (!complete && len(chunk) > window)) {
+			return false, chunk, nil, fmt.Errorf(
+				"%w: no separator ends within the first [%d] bytes",
+				ErrNoMatchInWindow,
+				window)
+		}
+
+		if !complete {
+			return false, chunk, nil, nil
+		}
+
+		leftOver := make([]byte, len(chunk)-match[1])
+		copy(leftOver, chunk[match[1]:])
+
+		return true, chunk[:match[0]], leftOver, nil
+	}
+}
+
+// firstNonEmptyMatch, returns the position of the first match of the regular expression in the data that is at least
+// one byte long, or nil when there is none.
+func firstNonEmptyMatch(re *regexp.Regexp, data []byte) []int {
+	match := re.FindIndex(data)
+
+	if match == nil || match[1] > match[0] {
+		return match
+	}
+
+	for _, match = range re.FindAllIndex(data, -1) {
+		if match[1] > match[0] {
+			return match
+		}
+	}
+
+	return nil
+}
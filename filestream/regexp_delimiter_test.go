@@ -0,0 +1,76 @@
+package filestream
+
+import (
+	"errors"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestDelimiteByRegexp(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		data    string
+		want    []string
+	}{
+		{"whitespace runs", `\s+`, "a  b\t\n c", []string{"a", "b", "c"}},
+		{"separator at the end", `\s+`, "a  b \n", []string{"a", "b"}},
+		{"separator lines", `(?m)^---\n`, "a\n---\nb\n---\n", []string{"a\n", "b\n"}},
+		{"no separator", `;`, "abc", []string{"abc"}},
+		{"empty matches skipped", `x*`, "axxb", []string{"a", "b"}},
+		{"empty", `;`, "", []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			re := regexp.MustCompile(tt.pattern)
+
+			got, err := collectChunks(t, tt.data, withDelimiterWithEOF(func() DataChunkDelimiterWithEOF {
+				return DelimiteByRegexp(re)
+			}))
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDelimiteByRegexpWithScanWindow(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    []string
+		wantErr error
+	}{
+		{"separators within the window", "abc;de;f", []string{"abc", "de", "f"}, nil},
+		{"separator ending the window", "abcd;e", []string{"abcd", "e"}, nil},
+		{"separator after the window", "abcdef;g", []string{}, ErrNoMatchInWindow},
+		{"no separator at all", "abcdefghij", []string{}, ErrNoMatchInWindow},
+		{"record after the window", "ab;cdefghij;", []string{"ab"}, ErrNoMatchInWindow},
+		{"last record within the window", "ab;cd", []string{"ab", "cd"}, nil},
+	}
+
+	re := regexp.MustCompile(`;+`)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := collectChunks(t, tt.data, withDelimiterWithEOF(func() DataChunkDelimiterWithEOF {
+				return DelimiteByRegexp(re, WithScanWindow(5))
+			}))
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
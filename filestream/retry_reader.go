@@ -0,0 +1,49 @@
+package filestream
+
+import (
+	"io"
+	"time"
+)
+
+// retryReader, an io.Reader that retries the reads of the underlying one failing with a retryable error.
+type retryReader struct {
+	reader      io.Reader
+	maxAttempts int
+	isRetryable func(error) bool
+	backoff     func(int) time.Duration
+}
+
+// NewRetryReader, wraps a data source, such as a network stream, so that any read failing with an error for which
+// isRetryable returns "true" is attempted again, waiting for backoff(attempt) between attempts, up to maxAttempts
+// attempts in total for the same read, after that the last error is returned. Since the retries happen inside the
+// reader, the processing of the data source never sees the transient errors and the data accumulated so far is kept.
+// Bytes returned along with a retryable error are returned as a successful read, the next read is the one retried.
+func NewRetryReader(
+	dataSource io.Reader,
+	maxAttempts int,
+	isRetryable func(error) bool,
+	backoff func(int) time.Duration) io.Reader {
+	return &retryReader{reader: dataSource, maxAttempts: maxAttempts, isRetryable: isRetryable, backoff: backoff}
+}
+
+func (r *retryReader) Read(p []byte) (int, error) {
+	for attempt := 1; ; attempt++ {
+		n, err := r.reader.Read(p)
+
+		if err == nil || err == io.EOF || !r.isRetryable(err) {
+			return n, err
+		}
+
+		if n > 0 {
+			return n, nil
+		}
+
+		if attempt >= r.maxAttempts {
+			return n, err
+		}
+
+		if r.backoff != nil {
+			time.Sleep(r.backoff(attempt))
+		}
+	}
+}
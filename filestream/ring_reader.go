@@ -0,0 +1,25 @@
+package filestream
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// NewRingReader, builds an io.Reader over a circular (ring) buffer, such as a fixed size log file where writes wrap
+// around, presenting the logical unwrapped stream so it can be handed straight to ProcessDataSourceInChunks. The head is
+// the offset of the oldest byte and the tail is the offset where the next write would happen, whenever the tail is
+// behind the head the data wraps around the end of the buffer and the reader continues from its start, so a record
+// split by the wrap is read as a single continuous one.
+// NOTE: a head equals to the tail is considered as an empty buffer.
+func NewRingReader(buffer []byte, head, tail int) (io.Reader, error) {
+	if head < 0 || head > len(buffer) || tail < 0 || tail > len(buffer) {
+		return nil, fmt.Errorf("ring offsets head [%d] and tail [%d] out of buffer of size [%d]", head, tail, len(buffer))
+	}
+
+	if tail >= head {
+		return bytes.NewReader(buffer[head:tail]), nil
+	}
+
+	return io.MultiReader(bytes.NewReader(buffer[head:]), bytes.NewReader(buffer[:tail])), nil
+}
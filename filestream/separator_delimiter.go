@@ -0,0 +1,25 @@
+package filestream
+
+import "bytes"
+
+// DelimiteBySeparator, builds a DataChunkDelimiter that splits the data at the first occurrence of the given separator,
+// which can be made of one or many bytes, such as the "\x1e" record separator or "||". Everything before the separator
+// is the chunk and everything after it is the left over, the separator itself is dropped. A separator cut by the end of
+// a read is simply not found yet, so the bytes it started with stay in the data until the next read completes it.
+// NOTE: an empty separator never splits anything.
+func DelimiteBySeparator(separator []byte) DataChunkDelimiter {
+	return func(chunk []byte) (bool, []byte, []byte) {
+		separatorIndex := bytes.Index(chunk, separator)
+
+		if len(separator) == 0 || separatorIndex < 0 {
+			return false, chunk, nil
+		}
+
+		leftOverStart := separatorIndex + len(separator)
+
+		leftOver := make([]byte, len(chunk)-leftOverStart)
+		copy(leftOver, chunk[leftOverStart:])
+
+		return true, chunk[:separatorIndex], leftOver
+	}
+}
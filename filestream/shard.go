@@ -0,0 +1,197 @@
+package filestream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Shard, a byte range of a data source, from Start (inclusive) to End (exclusive), that begins and ends at record
+// boundaries, so it can be processed independently of the others.
+type Shard struct {
+	Start int64
+	End   int64
+}
+
+// ComputeShards, splits a data source of the given size in up to n shards of roughly the same size, moving every
+// boundary forward to the end of the record it falls into, as found by the DataChunkDelimiter, so no record is split
+// between two shards. The shards cover the whole data source with no overlap, although fewer than n are returned when
+// the records are too large for all the boundaries to fall in different ones.
+// NOTE: the delimiter is run from the middle of records, so it must be able to find the end of a record without
+// having seen its beginning, as DelimiteByNewLine or DelimiteBySeparator do, and hold no state about the data.
+func ComputeShards(dataSource io.ReaderAt, size int64, n int, chunkDelimiter DataChunkDelimiter) ([]Shard, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("invalid shard count [%d]", n)
+	}
+
+	shards := make([]Shard, 0, n)
+	start := int64(0)
+
+	for i := 1; i <= n && start < size; i++ {
+		end := size
+
+		if i < n {
+			nominal := size * int64(i) / int64(n)
+
+			if nominal < start {
+				nominal = start
+			}
+
+			var err error
+			end, err = nextRecordBoundary(dataSource, size, nominal, chunkDelimiter)
+
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if end > start {
+			shards = append(shards, Shard{Start: start, End: end})
+			start = end
+		}
+	}
+
+	return shards, nil
+}
+
+// nextRecordBoundary, finds the offset right after the end of the record the given offset falls into. The search
+// starts at the byte before the offset, so an offset that is already the beginning of a record is kept as it is.
+func nextRecordBoundary(
+	dataSource io.ReaderAt,
+	size int64,
+	offset int64,
+	chunkDelimiter DataChunkDelimiter) (int64, error) {
+	if offset <= 0 {
+		return 0, nil
+	}
+
+	searchStart := offset - 1
+	window := make([]byte, 0, defaultChunkSize)
+	readAt := searchStart
+
+	for readAt < size {
+		part := make([]byte, defaultChunkSize)
+		n, err := dataSource.ReadAt(part, readAt)
+
+		if err != nil && !errors.Is(err, io.EOF) {
+			return 0, err
+		}
+
+		readAt += int64(n)
+		window = append(window, part[:n]...)
+
+		enough, _, leftOver := chunkDelimiter(window)
+
+		if enough {
+			// everything that is not left over was consumed by the record and its delimiter.
+			return searchStart + int64(len(window)-len(leftOver)), nil
+		}
+
+		if n == 0 {
+			break
+		}
+	}
+
+	return size, nil
+}
+
+// ProcessShardsParallel, splits a data source of the given size in up to workers shards, just as ComputeShards does,
+// and processes every shard concurrently, in its own goroutine, reading it chunkSize bytes at a time, so every record
+// is handed to the handler exactly once, no matter how many workers there are. The first shard to fail stops the
+// others as soon as they notice it and its error is the one returned, telling the byte range of the shard, the offset
+// of a ChunkError in it being relative to the start of the shard, while an ErrStopProcessing from the handler stops all
+// of them the same way but nil is returned.
+// NOTE: the handler is called from many goroutines at the same time, so it must be safe for concurrent use, and the
+// chunks of different shards are handled in no particular order, the delimiter must also suit ComputeShards.
+func ProcessShardsParallel(
+	dataSource io.ReaderAt,
+	size int64,
+	workers int,
+	chunkSize int,
+	chunkHandler DataChunkHandler,
+	chunkDelimiter DataChunkDelimiter,
+	opts ...ConcurrentOption) error {
+	if workers < 1 {
+		workers = 1
+	}
+
+	shards, err := ComputeShards(dataSource, size, workers, chunkDelimiter)
+
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var firstErr error
+	var stopped bool
+	var failOnce sync.Once
+	var wg sync.WaitGroup
+
+	shardHandler := func(b []byte) error {
+		err := chunkHandler(b)
+
+		if errors.Is(err, ErrStopProcessing) {
+			failOnce.Do(func() {
+				stopped = true
+				cancel()
+			})
+		}
+
+		return err
+	}
+
+	processShard := func(shard Shard) {
+		shardSource := io.NewSectionReader(dataSource, shard.Start, shard.End-shard.Start)
+		err := NewProcessor(
+			shardSource,
+			WithContext(ctx),
+			WithChunkSize(chunkSize),
+			WithDelimiter(chunkDelimiter)).Run(shardHandler)
+
+		// the shards stopped because of another one report the error of the context, which is not theirs.
+		if err == nil || (ctx.Err() != nil && errors.Is(err, context.Canceled)) {
+			return
+		}
+
+		failOnce.Do(func() {
+			firstErr = fmt.Errorf("shard [%d, %d): %w", shard.Start, shard.End, err)
+			cancel()
+		})
+	}
+
+	// the shards are all queued up front, so the workers, fewer than them along with a WorkerGroup, take them in turns.
+	pending := make(chan Shard, len(shards))
+
+	for _, shard := range shards {
+		pending <- shard
+	}
+
+	close(pending)
+
+	group := newConcurrentConfig(opts).group
+
+	for i := group.acquire(len(shards)); i > 0; i-- {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			defer group.release()
+
+			for shard := range pending {
+				processShard(shard)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if stopped {
+		return nil
+	}
+
+	return firstErr
+}
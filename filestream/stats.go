@@ -0,0 +1,166 @@
+package filestream
+
+import (
+	"io"
+	"time"
+)
+
+// Stats, how much work was done while processing a data source.
+type Stats struct {
+	// ChunksProcessed, the number of chunks the handler returned no error for.
+	ChunksProcessed int
+	// BytesRead, the number of bytes actually read from the data source.
+	BytesRead int64
+	// BytesEmitted, the number of bytes of all the chunks handed to the handler.
+	BytesEmitted int64
+	// DelimiterDuration, the time spent in all the calls to the delimiter, only measured along with
+	// WithDelimiterDuration, it is zero otherwise.
+	DelimiterDuration time.Duration
+	// Reads, the number of reads of the data source that returned data.
+	Reads int
+	// ReadsPerChunk, the average number of reads it took to collect each chunk handed to the handler, a high value
+	// means the chunk size is too small for the records of the data source, while a value below one means a single
+	// read holds many of them.
+	ReadsPerChunk float64
+}
+
+// countingReader, an io.Reader that counts the bytes read from the underlying one and, when reads is given, the reads
+// that returned them.
+type countingReader struct {
+	src   io.Reader
+	count *int64
+	reads *int
+}
+
+func (r countingReader) Read(p []byte) (int, error) {
+	n, err := r.src.Read(p)
+
+	if n > 0 {
+		*r.count += int64(n)
+
+		if r.reads != nil {
+			*r.reads++
+		}
+	}
+
+	return n, err
+}
+
+// StatsOption, configures what ProcessDataSourceInChunksWithStats measures besides the counters it always fills.
+type StatsOption func(*statsConfig)
+
+type statsConfig struct {
+	delimiterDuration bool
+}
+
+// WithDelimiterDuration, sets the time spent in the delimiter to be measured, in Stats.DelimiterDuration.
+// NOTE: the delimiter is then called for every read, the line delimiters included, which are otherwise left to a faster
+// scan that never calls them, so it slows down the processing it measures.
+func WithDelimiterDuration() StatsOption {
+	return func(c *statsConfig) {
+		c.delimiterDuration = true
+	}
+}
+
+// ProcessDataSourceInChunksWithStats, same as ProcessDataSourceInChunks but it also returns the Stats of the
+// processing, which are filled even when an error is returned, describing the work done until then.
+func ProcessDataSourceInChunksWithStats(
+	dataSource io.Reader,
+	chunkSize int,
+	chunkHandler DataChunkHandler,
+	chunkDelimiter DataChunkDelimiter,
+	opts ...StatsOption) (Stats, error) {
+	var config statsConfig
+
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	var stats Stats
+	chunksHandled := 0
+
+	countingHandler := func(chunk []byte) error {
+		stats.BytesEmitted += int64(len(chunk))
+		chunksHandled++
+
+		err := chunkHandler(chunk)
+
+		if err == nil {
+			stats.ChunksProcessed++
+		}
+
+		return err
+	}
+
+	processorOpts := []Option{WithChunkSize(chunkSize), WithDelimiter(chunkDelimiter)}
+
+	if config.delimiterDuration {
+		processorOpts = append(processorOpts, WithDelimiterTiming(func(d time.Duration) { stats.DelimiterDuration += d }))
+	}
+
+	countingSource := countingReader{src: dataSource, count: &stats.BytesRead, reads: &stats.Reads}
+	err := NewProcessor(countingSource, processorOpts...).Run(countingHandler)
+
+	if chunksHandled > 0 {
+		stats.ReadsPerChunk = float64(stats.Reads) / float64(chunksHandled)
+	}
+
+	return stats, err
+}
+
+// TimeDelimiter, wraps the DataChunkDelimiter so the time taken by each one of its calls is handed to onCall, which
+// helps telling whether a custom delimiter is the bottleneck of the processing. Keep in mind the engine calls the
+// delimiter again on all the data collected so far after every read, so the number of calls depends on the chunk size.
+func TimeDelimiter(onCall func(time.Duration), chunkDelimiter DataChunkDelimiter) DataChunkDelimiter {
+	return func(chunk []byte) (bool, []byte, []byte) {
+		start := time.Now()
+		enough, chunkToBeProcessed, leftOver := chunkDelimiter(chunk)
+		onCall(time.Since(start))
+
+		return enough, chunkToBeProcessed, leftOver
+	}
+}
+
+// Progress, how much of a data source was processed so far, as sent by a Processor built with WithProgressChannel.
+type Progress struct {
+	BytesRead       int64
+	ChunksProcessed int
+}
+
+// progressReporter, forwards the progress of a processing to a channel without ever blocking it, while the consumer is
+// busy the updates are coalesced, only the latest one is kept, which is also the one delivered last.
+type progressReporter struct {
+	latest chan Progress
+	done   chan struct{}
+}
+
+func newProgressReporter(progress chan<- Progress) *progressReporter {
+	r := &progressReporter{latest: make(chan Progress, 1), done: make(chan struct{})}
+
+	go func() {
+		defer close(r.done)
+		defer close(progress)
+
+		for p := range r.latest {
+			progress <- p
+		}
+	}()
+
+	return r
+}
+
+// report, replaces the update waiting to be delivered, if there is one, by the given one.
+func (r *progressReporter) report(p Progress) {
+	select {
+	case <-r.latest:
+	default:
+	}
+
+	r.latest <- p
+}
+
+// finish, reports the final update, the channel is closed once it is delivered.
+func (r *progressReporter) finish(p Progress) {
+	r.report(p)
+	close(r.latest)
+}
@@ -0,0 +1,144 @@
+package filestream
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// slowDelimiter, how long every call to the delimiter built by newSlowDelimiter takes at least.
+const slowDelimiter = 2 * time.Millisecond
+
+// newSlowDelimiter, a DelimiteByNewLine taking at least slowDelimiter for every call, which counts them in calls.
+func newSlowDelimiter(calls *int) DataChunkDelimiter {
+	return func(chunk []byte) (bool, []byte, []byte) {
+		*calls++
+		time.Sleep(slowDelimiter)
+
+		return DelimiteByNewLine(chunk)
+	}
+}
+
+func TestWithDelimiterTiming(t *testing.T) {
+	tests := []struct {
+		name           string
+		data           string
+		chunkDelimiter func(calls *int) DataChunkDelimiter
+		wantSlow       bool
+	}{
+		{"slow delimiter", "first\nsecond\nthird", newSlowDelimiter, true},
+		{"line delimiter", "first\nsecond\nthird", func(*int) DataChunkDelimiter { return DelimiteByNewLine }, false},
+		{
+			"line delimiter with carriage returns",
+			"first\r\nsecond\r\nthird",
+			func(*int) DataChunkDelimiter { return DelimiteByLine },
+			false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var delimiterCalls, timedCalls int
+			var total time.Duration
+			var chunks []string
+
+			p := NewProcessor(
+				strings.NewReader(tt.data),
+				WithChunkSize(4),
+				WithDelimiter(tt.chunkDelimiter(&delimiterCalls)),
+				WithDelimiterTiming(func(d time.Duration) {
+					timedCalls++
+					total += d
+				}))
+
+			err := p.Run(func(chunk []byte) error {
+				chunks = append(chunks, string(chunk))
+				return nil
+			})
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if want := []string{"first", "second", "third"}; !reflect.DeepEqual(chunks, want) {
+				t.Errorf("got %q, want %q", chunks, want)
+			}
+
+			if timedCalls == 0 {
+				t.Fatalf("no call to the delimiter was timed")
+			}
+
+			if tt.wantSlow && timedCalls != delimiterCalls {
+				t.Errorf("[%d] calls timed, the delimiter was called [%d] times", timedCalls, delimiterCalls)
+			}
+
+			if tt.wantSlow && total < time.Duration(delimiterCalls)*slowDelimiter {
+				t.Errorf("[%v] timed for [%d] calls of at least [%v]", total, delimiterCalls, slowDelimiter)
+			}
+		})
+	}
+}
+
+func TestProcessDataSourceInChunksWithStats(t *testing.T) {
+	tests := []struct {
+		name           string
+		chunkDelimiter func(calls *int) DataChunkDelimiter
+		opts           []StatsOption
+		wantTimed      bool
+		wantReads      int
+	}{
+		{"slow delimiter", newSlowDelimiter, nil, false, 5},
+		{"slow delimiter timed", newSlowDelimiter, []StatsOption{WithDelimiterDuration()}, true, 5},
+		// the line scan reads ahead in a buffer of at least 16 bytes, whatever the chunk size is.
+		{"line delimiter", func(*int) DataChunkDelimiter { return DelimiteByNewLine }, nil, false, 2},
+		{
+			"line delimiter timed",
+			func(*int) DataChunkDelimiter { return DelimiteByNewLine },
+			[]StatsOption{WithDelimiterDuration()},
+			true,
+			5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var delimiterCalls int
+
+			stats, err := ProcessDataSourceInChunksWithStats(
+				strings.NewReader("first\nsecond\nthird"),
+				4,
+				func([]byte) error { return nil },
+				tt.chunkDelimiter(&delimiterCalls),
+				tt.opts...)
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if stats.ChunksProcessed != 3 {
+				t.Errorf("[%d] chunks processed, want 3", stats.ChunksProcessed)
+			}
+
+			if stats.BytesRead != 18 {
+				t.Errorf("[%d] bytes read, want 18", stats.BytesRead)
+			}
+
+			if stats.Reads != tt.wantReads {
+				t.Errorf("[%d] reads, want [%d]", stats.Reads, tt.wantReads)
+			}
+
+			if !tt.wantTimed && stats.DelimiterDuration != 0 {
+				t.Errorf("[%v] spent in the delimiter, want it not measured", stats.DelimiterDuration)
+			}
+
+			if tt.wantTimed && stats.DelimiterDuration < time.Duration(delimiterCalls)*slowDelimiter {
+				t.Errorf(
+					"[%v] spent in [%d] delimiter calls of at least [%v]",
+					stats.DelimiterDuration,
+					delimiterCalls,
+					slowDelimiter)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package filestream
+
+import "bytes"
+
+// SubStream, an independent stream that can be found inside a data source made of several concatenated ones, it is
+// recognized by the magic header it starts with and its records are split by its own delimiter.
+type SubStream struct {
+	Magic     []byte
+	Delimiter DataChunkDelimiter
+}
+
+// DelimiteBySubStreams, builds a DataChunkDelimiter for data sources made of several independent streams concatenated,
+// each one starting with the magic header of one of the given SubStream. The magic header is consumed and the records
+// that follow it are split by the delimiter of that SubStream until the magic header of the next one is found, the
+// bytes right before a magic header are always the end of a record, even if the delimiter in use did not say so.
+// NOTE: the data source is expected to start with a magic header, until one is found nothing is emitted, and magic
+// headers are not expected to show up inside the records.
+func DelimiteBySubStreams(subStreams ...SubStream) DataChunkDelimiter {
+	var current DataChunkDelimiter
+
+	return func(chunk []byte) (bool, []byte, []byte) {
+		records := chunk
+
+		for _, s := range subStreams {
+			// a magic header may be cut by the end of the read, more data is needed to tell.
+			if len(chunk) < len(s.Magic) && bytes.HasPrefix(s.Magic, chunk) {
+				return false, chunk, nil
+			}
+
+			if bytes.HasPrefix(chunk, s.Magic) {
+				current = s.Delimiter
+				records = chunk[len(s.Magic):]
+
+				break
+			}
+		}
+
+		if current == nil {
+			return false, chunk, nil
+		}
+
+		nextMagic := indexOfNextMagic(records, subStreams)
+
+		if nextMagic < 0 {
+			enough, record, leftOver := current(records)
+
+			if !enough {
+				return false, chunk, nil
+			}
+
+			return true, record, leftOver
+		}
+
+		enough, record, leftOver := current(records[:nextMagic])
+
+		// whatever is left before the next magic header is the last record of the current sub stream.
+		if !enough {
+			record = records[:nextMagic]
+			leftOver = nil
+		}
+
+		nextStream := make([]byte, 0, len(leftOver)+len(records)-nextMagic)
+		nextStream = append(nextStream, leftOver...)
+		nextStream = append(nextStream, records[nextMagic:]...)
+
+		return true, record, nextStream
+	}
+}
+
+// indexOfNextMagic, returns the index of the first magic header, of any of the sub streams, found in the data, or -1 if
+// there is none.
+func indexOfNextMagic(data []byte, subStreams []SubStream) int {
+	first := -1
+
+	for _, s := range subStreams {
+		i := bytes.Index(data, s.Magic)
+
+		if i >= 0 && (first < 0 || i < first) {
+			first = i
+		}
+	}
+
+	return first
+}
@@ -0,0 +1,61 @@
+package filestream
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ProcessTarEntries, processes in chunks the content of every regular file of a tar archive read as a stream, one
+// after the other in the order they are stored, handing each chunk to the handler along with the name of the file it
+// came from, just as ProcessZipEntries does for zip archives. Directories, links and any other entry that is not a
+// regular file are skipped. Just as there, an ErrStopProcessing returned by the handler stops the processing of the
+// whole archive, and any other error is returned along with the name of the file it was found in. A compressed
+// archive, such as a ".tar.gz" one, is processed by wrapping the data source with its decompressor first,
+// NewGzipSource for instance.
+// NOTE: the same delimiter is used for all the files, a delimiter holding state about the data, such as the one built
+// by DelimiteByJSONAuto, would carry it from one file into the next.
+func ProcessTarEntries(
+	dataSource io.Reader,
+	chunkSize int,
+	chunkHandler func(entryName string, chunk []byte) error,
+	chunkDelimiter DataChunkDelimiter) error {
+	tarReader := tar.NewReader(dataSource)
+
+	for {
+		entryHeader, err := tarReader.Next()
+
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if !entryHeader.FileInfo().Mode().IsRegular() {
+			continue
+		}
+
+		// stopped, whether the handler stopped the processing of the whole archive, not only of the current file.
+		stopped := false
+
+		entryHandler := func(b []byte) error {
+			err := chunkHandler(entryHeader.Name, b)
+			stopped = errors.Is(err, ErrStopProcessing)
+
+			return err
+		}
+
+		err = ProcessDataSourceInChunks(tarReader, chunkSize, entryHandler, chunkDelimiter)
+
+		if err != nil {
+			return fmt.Errorf("tar entry [%s]: %w", entryHeader.Name, err)
+		}
+
+		if stopped {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package filestream
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// tarArchive, a tar archive holding the given regular files, in the order they are given, as name and content pairs,
+// along with a directory before them.
+func tarArchive(t *testing.T, files ...string) []byte {
+	t.Helper()
+
+	var archive bytes.Buffer
+	writer := tar.NewWriter(&archive)
+
+	if err := writer.WriteHeader(&tar.Header{Name: "dir/", Typeflag: tar.TypeDir, Mode: 0755}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for i := 0; i+1 < len(files); i += 2 {
+		header := &tar.Header{Name: files[i], Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(files[i+1]))}
+
+		if err := writer.WriteHeader(header); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if _, err := writer.Write([]byte(files[i+1])); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return archive.Bytes()
+}
+
+func TestProcessTarEntries(t *testing.T) {
+	archive := tarArchive(t, "a.txt", "a1\na2\n", "b.txt", "b1")
+	var got []string
+
+	chunkHandler := func(entryName string, chunk []byte) error {
+		got = append(got, entryName+":"+string(chunk))
+		return nil
+	}
+
+	if err := ProcessTarEntries(bytes.NewReader(archive), 4, chunkHandler, DelimiteByNewLine); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{"a.txt:a1", "a.txt:a2", "b.txt:b1"}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestProcessTarEntriesStop(t *testing.T) {
+	archive := tarArchive(t, "a.txt", "a1\na2\n", "b.txt", "b1\n")
+	var got []string
+
+	chunkHandler := func(entryName string, chunk []byte) error {
+		got = append(got, entryName+":"+string(chunk))
+		return ErrStopProcessing
+	}
+
+	if err := ProcessTarEntries(bytes.NewReader(archive), 4, chunkHandler, DelimiteByNewLine); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{"a.txt:a1"}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestProcessTarEntriesError(t *testing.T) {
+	archive := tarArchive(t, "a.txt", "a1\n", "b.txt", "b1\n")
+	errHandler := errors.New("handler failed")
+
+	chunkHandler := func(entryName string, chunk []byte) error {
+		if entryName == "b.txt" {
+			return errHandler
+		}
+
+		return nil
+	}
+
+	err := ProcessTarEntries(bytes.NewReader(archive), 4, chunkHandler, DelimiteByNewLine)
+
+	if !errors.Is(err, errHandler) {
+		t.Fatalf("got error %v, want %v", err, errHandler)
+	}
+
+	if !strings.Contains(err.Error(), "b.txt") {
+		t.Errorf("the error does not tell the file: %v", err)
+	}
+}
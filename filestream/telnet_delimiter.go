@@ -0,0 +1,111 @@
+package filestream
+
+const (
+	telnetIAC  = byte(255)
+	telnetDONT = byte(254)
+	telnetWILL = byte(251)
+	telnetSB   = byte(250)
+	telnetSE   = byte(240)
+
+	carriageReturnByte = byte('\r')
+)
+
+// DelimiteByTelnet, one implementation of DataChunkDelimiterWithEOF for Telnet like streams, where the data is split
+// in lines but may be interleaved with commands introduced by the IAC (0xFF) byte. Commands are consumed and discarded,
+// including option negotiation (WILL, WONT, DO, DONT plus the option byte) and sub negotiation (SB ... IAC SE), while
+// a doubled IAC is un-escaped into a single literal 0xFF byte. Every line found in the data is emitted without its
+// CR LF terminator, and so is the data after the last one, decoded just the same once the data source ends. Whenever a
+// command is not complete yet, more data is requested before deciding anything, unless the data source is over, in
+// which case it is dropped.
+func DelimiteByTelnet(chunk []byte, atEOF bool) (bool, []byte, []byte, error) {
+	data := make([]byte, 0, len(chunk))
+
+	for i := 0; i < len(chunk); i++ {
+		c := chunk[i]
+
+		if c == newLineByte {
+			leftOver := make([]byte, len(chunk)-i-1)
+			copy(leftOver, chunk[i+1:])
+
+			return true, removeTrailingCarriageReturn(data), leftOver, nil
+		}
+
+		if c != telnetIAC {
+			data = append(data, c)
+			continue
+		}
+
+		commandEnd, literal, complete := parseTelnetCommand(chunk, i)
+
+		// the command started at this read but its last bytes are still in the data source, or never will be.
+		if !complete && atEOF {
+			break
+		}
+
+		if !complete {
+			return false, chunk, nil, nil
+		}
+
+		if literal {
+			data = append(data, telnetIAC)
+		}
+
+		i = commandEnd
+	}
+
+	// the last line is not terminated, but it is handed over decoded rather than as it was read.
+	if atEOF {
+		return false, removeTrailingCarriageReturn(data), nil, nil
+	}
+
+	return false, chunk, nil, nil
+}
+
+// removeTrailingCarriageReturn, removes a single carriage return from the end of the line, if there is one.
+func removeTrailingCarriageReturn(line []byte) []byte {
+	if len(line) > 0 && line[len(line)-1] == carriageReturnByte {
+		return line[:len(line)-1]
+	}
+
+	return line
+}
+
+// parseTelnetCommand, parses the command that starts with the IAC byte placed at the given index and returns the index
+// of its last byte, whether the command is actually an escaped literal IAC and whether the whole command is present in
+// the chunk.
+func parseTelnetCommand(chunk []byte, start int) (int, bool, bool) {
+	if start+1 >= len(chunk) {
+		return 0, false, false
+	}
+
+	command := chunk[start+1]
+
+	switch {
+	case command == telnetIAC:
+		return start + 1, true, true
+	case command >= telnetWILL && command <= telnetDONT:
+		// option negotiation is followed by a single option byte.
+		if start+2 >= len(chunk) {
+			return 0, false, false
+		}
+
+		return start + 2, false, true
+	case command == telnetSB:
+		// sub negotiation goes on until an IAC SE pair, any IAC IAC pair inside it is an escaped literal.
+		for i := start + 2; i < len(chunk)-1; i++ {
+			if chunk[i] != telnetIAC {
+				continue
+			}
+
+			if chunk[i+1] == telnetSE {
+				return i + 1, false, true
+			}
+
+			i++
+		}
+
+		return 0, false, false
+	}
+
+	return start + 1, false, true
+}
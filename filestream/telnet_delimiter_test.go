@@ -0,0 +1,51 @@
+package filestream
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestDelimiteByTelnet(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []string
+	}{
+		{"lines", "ab\r\ncd\r\n", []string{"ab", "cd"}},
+		{"option negotiation", "a\xff\xfb\x01b\r\n", []string{"ab"}},
+		{"sub negotiation", "a\xff\xfa\x18\x01\xff\xff\xff\xf0b\n", []string{"ab"}},
+		{"escaped IAC", "a\xff\xffb\r\n", []string{"a\xffb"}},
+		{"last line without terminator", "x\r\nab\xff\xffc", []string{"x", "ab\xffc"}},
+		{"last line with a carriage return", "ab\r", []string{"ab"}},
+		{"incomplete command at the end", "ab\xff\xfb", []string{"ab"}},
+		{"only commands at the end", "ab\n\xff\xfb\x01", []string{"ab"}},
+		{"empty", "", []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := collectChunks(t, tt.data, func() []Option {
+				return []Option{WithDelimiterWithEOF(DelimiteByTelnet)}
+			})
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDelimiteByTelnetStrictTermination(t *testing.T) {
+	_, err := collectChunks(t, "ab\r\ncd\xff\xff", func() []Option {
+		return []Option{WithDelimiterWithEOF(DelimiteByTelnet), WithStrictTermination(true)}
+	})
+
+	if !errors.Is(err, ErrTrailingData) {
+		t.Errorf("got error %v, want %v", err, ErrTrailingData)
+	}
+}
@@ -0,0 +1,42 @@
+package filestream
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// DataChunkHandlerWithTerminator, same as DataChunkHandler but it also receives the bytes that terminated the chunk,
+// such as "\n" or "\r\n", which are empty when the chunk was ended by the end of the data source.
+type DataChunkHandlerWithTerminator func(chunk []byte, terminator []byte) error
+
+// ProcessDataSourceInChunksWithTerminator, same as ProcessDataSourceInChunks but the handler also receives the bytes
+// that terminated each chunk, for formats whose records are parsed differently depending on how they end. The
+// terminator is told from the data the delimiter was given, being the bytes between the end of the chunk and its left
+// over, so the delimiter does not need to report it.
+// NOTE: just as for ChunkMeta, the terminator is only accurate for delimiters that hand back the chunk from the
+// beginning of the data they were given and the left over as they found it, the handler receives a nil terminator
+// whenever the chunk does not start the data, while delimiters dropping leading bytes of the left over, as
+// DelimiteByAnyByte does when collapsing runs of delimiters, report only part of the terminator. A new line terminating the last chunk is
+// still reported when the delimiter itself did not recognize it.
+func ProcessDataSourceInChunksWithTerminator(
+	dataSource io.Reader,
+	chunkSize int,
+	chunkHandler DataChunkHandlerWithTerminator,
+	chunkDelimiter DataChunkDelimiter) error {
+	scanner := newChunkScanner(context.Background(), dataSource, chunkSize, 0, chunkDelimiter)
+
+	for scanner.Scan() {
+		err := chunkHandler(scanner.Bytes(), scanner.terminator())
+
+		if errors.Is(err, ErrStopProcessing) {
+			return nil
+		}
+
+		if err != nil {
+			return &ChunkError{ChunkMeta: ChunkMeta{Offset: scanner.chunkOffset, Index: scanner.chunkIndex}, Err: err}
+		}
+	}
+
+	return scanner.Err()
+}
@@ -0,0 +1,70 @@
+package filestream
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// tlvTypeBytes, the size of the type field leading every record of a TLV stream.
+const tlvTypeBytes = 1
+
+// DelimiteByTLV, builds a DataChunkDelimiterWithError for type-length-value streams, such as BER encoded ones, where
+// every record is a type byte followed by the length of its value, written in lengthBytes bytes, from 1 to 8, in the
+// given byte order, and then the value itself. The whole record, type and length included, is emitted, or just its
+// value when valueOnly is "true", and only once it is all present, no matter how many reads the header or the value
+// take.
+// NOTE: it is meant for NewProcessor along with WithDelimiterWithError, and along with WithMaxChunkSize to bound the
+// memory a corrupt length could make it collect.
+func DelimiteByTLV(lengthBytes int, byteOrder binary.ByteOrder, valueOnly bool) DataChunkDelimiterWithError {
+	headerBytes := tlvTypeBytes + lengthBytes
+
+	return func(chunk []byte) (bool, []byte, []byte, error) {
+		if lengthBytes < 1 || lengthBytes > 8 {
+			return false, chunk, nil, fmt.Errorf("invalid TLV length size [%d], it must be from 1 to 8 bytes", lengthBytes)
+		}
+
+		if len(chunk) < headerBytes {
+			return false, chunk, nil, nil
+		}
+
+		length := readLengthPrefix(chunk[tlvTypeBytes:headerBytes], byteOrder)
+
+		if uint64(len(chunk)-headerBytes) < length {
+			return false, chunk, nil, nil
+		}
+
+		recordEnd := headerBytes + int(length)
+
+		leftOver := make([]byte, len(chunk)-recordEnd)
+		copy(leftOver, chunk[recordEnd:])
+
+		if valueOnly {
+			return true, chunk[headerBytes:recordEnd], leftOver, nil
+		}
+
+		return true, chunk[:recordEnd], leftOver, nil
+	}
+}
+
+// TLVRecordHandler, function that handles a record of a TLV stream already split in its type, the length of its value
+// as declared by its header and its value.
+type TLVRecordHandler func(recordType byte, length uint64, value []byte) error
+
+// HandleTLVRecords, builds a DataChunkHandler for the whole records emitted by DelimiteByTLV, with valueOnly "false"
+// and the same length size and byte order, that hands the type, length and value of every record to the handler, so
+// the header the delimiter already checked is split off instead of parsed over again by the handler. A chunk shorter
+// than the header, only possible for the last one of a truncated data source, stops the processing with an
+// ErrShortRecord.
+func HandleTLVRecords(lengthBytes int, byteOrder binary.ByteOrder, recordHandler TLVRecordHandler) DataChunkHandler {
+	headerBytes := tlvTypeBytes + lengthBytes
+
+	return func(b []byte) error {
+		if len(b) < headerBytes {
+			return fmt.Errorf("%w: [%d] bytes, a TLV header takes [%d] bytes", ErrShortRecord, len(b), headerBytes)
+		}
+
+		length := readLengthPrefix(b[tlvTypeBytes:headerBytes], byteOrder)
+
+		return recordHandler(b[0], length, b[headerBytes:])
+	}
+}
@@ -0,0 +1,42 @@
+package filestream
+
+import (
+	"bytes"
+	"errors"
+	"unicode/utf8"
+)
+
+// UTF8Policy, determinates what ApplyUTF8Policy, and a Processor built with WithUTF8Policy, does with chunks containing
+// invalid UTF-8 sequences.
+type UTF8Policy int
+
+const (
+	// UTF8PolicyIgnore, chunks are handled as they are, valid or not.
+	UTF8PolicyIgnore UTF8Policy = iota
+	// UTF8PolicyReplace, every run of invalid bytes is replaced by the unicode replacement character (U+FFFD).
+	UTF8PolicyReplace
+	// UTF8PolicyReject, processing stops with ErrInvalidUTF8 at the first invalid chunk.
+	UTF8PolicyReject
+)
+
+var (
+	ErrInvalidUTF8 = errors.New("chunk contains invalid UTF-8")
+
+	utf8ReplacementCharacter = []byte(string(utf8.RuneError))
+)
+
+// ApplyUTF8Policy, wraps a DataChunkHandler validating every chunk as UTF-8 before handing it over, applying the given
+// UTF8Policy to the chunks that are not valid.
+func ApplyUTF8Policy(policy UTF8Policy, chunkHandler DataChunkHandler) DataChunkHandler {
+	return func(b []byte) error {
+		if policy == UTF8PolicyIgnore || utf8.Valid(b) {
+			return chunkHandler(b)
+		}
+
+		if policy == UTF8PolicyReject {
+			return ErrInvalidUTF8
+		}
+
+		return chunkHandler(bytes.ToValidUTF8(b, utf8ReplacementCharacter))
+	}
+}
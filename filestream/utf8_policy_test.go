@@ -0,0 +1,48 @@
+package filestream
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWithUTF8Policy(t *testing.T) {
+	data := "valid\nin\xffvalid\xc3\nlast é"
+
+	tests := []struct {
+		name    string
+		policy  UTF8Policy
+		want    []string
+		wantErr error
+	}{
+		{"ignore", UTF8PolicyIgnore, []string{"valid", "in\xffvalid\xc3", "last é"}, nil},
+		{"replace", UTF8PolicyReplace, []string{"valid", "in\ufffdvalid\ufffd", "last é"}, nil},
+		{"reject", UTF8PolicyReject, []string{"valid"}, ErrInvalidUTF8},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+
+			err := NewProcessor(strings.NewReader(data), WithUTF8Policy(tt.policy)).Run(func(chunk []byte) error {
+				got = append(got, string(chunk))
+				return nil
+			})
+
+			if !errors.Is(err, tt.wantErr) || (err != nil) != (tt.wantErr != nil) {
+				t.Fatalf("got error [%v], want [%v]", err, tt.wantErr)
+			}
+
+			var chunkErr *ChunkError
+
+			if tt.wantErr != nil && (!errors.As(err, &chunkErr) || chunkErr.Index != 2) {
+				t.Errorf("got error [%v], want it at chunk [2]", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
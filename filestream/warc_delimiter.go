@@ -0,0 +1,78 @@
+package filestream
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// ErrMalformedWARCRecord, returned by DelimiteByWARCRecord for a record whose content is not followed by the two CRLF
+// closing it, such as one declaring the wrong "Content-Length".
+var ErrMalformedWARCRecord = errors.New("malformed WARC record")
+
+var (
+	warcBlockSeparator      = []byte("\r\n\r\n")
+	warcHeaderLineSeparator = []byte("\r\n")
+	warcContentLengthHeader = []byte("content-length")
+)
+
+// DelimiteByWARCRecord, one implementation of DataChunkDelimiterWithError for WARC (web archive) files, each record is
+// made of a header block finished by an empty line, followed by as many bytes of content as declared by its
+// "Content-Length" header and two CRLF closing the record. The whole record, header block and content, is emitted as a
+// chunk while the closing CRLF pair is consumed. Nothing is emitted until the whole content and its closing pair are
+// present, and anything else in place of the closing pair, as with a wrong "Content-Length", stops the processing with
+// an ErrMalformedWARCRecord, since the records after it could not be told apart anymore.
+// NOTE: a record with no "Content-Length" header is considered to have no content.
+func DelimiteByWARCRecord(chunk []byte) (bool, []byte, []byte, error) {
+	headerEnd := bytes.Index(chunk, warcBlockSeparator)
+
+	if headerEnd < 0 {
+		return false, chunk, nil, nil
+	}
+
+	contentStart := headerEnd + len(warcBlockSeparator)
+	contentLength := warcContentLength(chunk[:headerEnd])
+	recordEnd := contentStart + contentLength
+	end := recordEnd + len(warcBlockSeparator)
+
+	if end > len(chunk) {
+		return false, chunk, nil, nil
+	}
+
+	if closing := chunk[recordEnd:end]; !bytes.Equal(closing, warcBlockSeparator) {
+		return false, chunk, nil, fmt.Errorf(
+			"%w: the [%d] bytes of content declared are followed by %q instead of %q",
+			ErrMalformedWARCRecord,
+			contentLength,
+			closing,
+			warcBlockSeparator)
+	}
+
+	leftOver := make([]byte, len(chunk)-end)
+	copy(leftOver, chunk[end:])
+
+	return true, chunk[:recordEnd], leftOver, nil
+}
+
+// warcContentLength, looks for the "Content-Length" header, case insensitively, in a WARC header block and returns its
+// value, zero is returned when the header is missing or its value is not a valid length.
+func warcContentLength(headerBlock []byte) int {
+	for _, line := range bytes.Split(headerBlock, warcHeaderLineSeparator) {
+		colon := bytes.IndexByte(line, ':')
+
+		if colon < 0 || !bytes.EqualFold(bytes.TrimSpace(line[:colon]), warcContentLengthHeader) {
+			continue
+		}
+
+		length, err := strconv.Atoi(string(bytes.TrimSpace(line[colon+1:])))
+
+		if err != nil || length < 0 {
+			return 0
+		}
+
+		return length
+	}
+
+	return 0
+}
@@ -0,0 +1,51 @@
+package filestream
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestDelimiteByWARCRecord(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    []string
+		wantErr error
+	}{
+		{
+			"records",
+			warcRecord + "WARC/1.0\r\n\r\n\r\n\r\n" + warcRecord,
+			[]string{warcRecord[:len(warcRecord)-4], "WARC/1.0\r\n\r\n", warcRecord[:len(warcRecord)-4]},
+			nil,
+		},
+		{
+			"content length too short",
+			warcRecord + "WARC/1.0\r\nContent-Length: 3\r\n\r\nhello\r\n\r\n" + warcRecord,
+			[]string{warcRecord[:len(warcRecord)-4]},
+			ErrMalformedWARCRecord,
+		},
+		{
+			"content length too long",
+			"WARC/1.0\r\nContent-Length: 6\r\n\r\nhello\r\n\r\n" + warcRecord,
+			[]string{},
+			ErrMalformedWARCRecord,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := collectChunks(t, tt.data, func() []Option {
+				return []Option{WithDelimiterWithError(DelimiteByWARCRecord)}
+			})
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package filestream
+
+// WorkerGroup, a limit shared by any number of concurrent processings, such as the ones of
+// ProcessDataSourceInChunksConcurrent, ProcessSharded, ProcessShardsParallel and TransformParallelOrdered running at the
+// same time across a service, for how many worker goroutines they start altogether, whatever the number of workers
+// each one of them was given, when they are given the group with WithWorkerGroup. Limit and LimitTransformer bound how
+// many handlers and transformers run at once instead, for any other code calling them concurrently.
+type WorkerGroup struct {
+	slots chan struct{}
+}
+
+// NewWorkerGroup, builds a WorkerGroup allowing up to limit workers, handlers or transformers to run at once, a limit
+// smaller than one is handled as one.
+func NewWorkerGroup(limit int) *WorkerGroup {
+	if limit < 1 {
+		limit = 1
+	}
+
+	return &WorkerGroup{slots: make(chan struct{}, limit)}
+}
+
+// Limit, wraps a DataChunkHandler so every call to it waits for a slot of the group, which is given back as soon as the
+// handler returns.
+func (g *WorkerGroup) Limit(chunkHandler DataChunkHandler) DataChunkHandler {
+	return func(b []byte) error {
+		g.slots <- struct{}{}
+		defer func() { <-g.slots }()
+
+		return chunkHandler(b)
+	}
+}
+
+// LimitTransformer, same as Limit but for a DataChunkTransformer.
+func (g *WorkerGroup) LimitTransformer(transformer DataChunkTransformer) DataChunkTransformer {
+	return func(b []byte) ([]byte, error) {
+		g.slots <- struct{}{}
+		defer func() { <-g.slots }()
+
+		return transformer(b)
+	}
+}
+
+// acquire, takes the slots for up to n workers of a processing and returns how many it took, every one of them must be
+// given back by release once its worker is done. Only the first slot is waited for, so every processing makes progress
+// with at least one worker, while the others are only taken when they are free right away, since a processing waiting
+// for more slots while holding some could wait forever for the ones held by another processing doing the same. A nil
+// group takes no slot at all and allows all the n workers, and so does a processing with no worker to start.
+func (g *WorkerGroup) acquire(n int) int {
+	if g == nil || n < 1 {
+		return n
+	}
+
+	g.slots <- struct{}{}
+	acquired := 1
+
+	for acquired < n {
+		select {
+		case g.slots <- struct{}{}:
+			acquired++
+		default:
+			return acquired
+		}
+	}
+
+	return acquired
+}
+
+// release, gives back one of the slots taken by acquire.
+func (g *WorkerGroup) release() {
+	if g != nil {
+		<-g.slots
+	}
+}
+
+// ConcurrentOption, configures the concurrent processings, such as ProcessDataSourceInChunksConcurrent.
+type ConcurrentOption func(*concurrentConfig)
+
+type concurrentConfig struct {
+	group *WorkerGroup
+}
+
+// WithWorkerGroup, sets the WorkerGroup the workers of the processing are drawn from, so it starts as many of them as
+// there are slots free, at most the number it was given and at least one, waiting for a slot if there is none. The
+// slots are given back as the workers finish.
+// NOTE: the goroutine reading the data source is not a worker, it does not take a slot of the group.
+func WithWorkerGroup(group *WorkerGroup) ConcurrentOption {
+	return func(c *concurrentConfig) {
+		c.group = group
+	}
+}
+
+// newConcurrentConfig, the configuration built by the options given.
+func newConcurrentConfig(opts []ConcurrentOption) concurrentConfig {
+	var config concurrentConfig
+
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	return config
+}
@@ -0,0 +1,123 @@
+package filestream
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// runningCounter, counts the handlers running at the same moment and the most of them that ever were.
+type runningCounter struct {
+	mu      sync.Mutex
+	running int
+	max     int
+	handled int
+}
+
+func (c *runningCounter) handle([]byte) error {
+	c.mu.Lock()
+	c.running++
+	c.handled++
+
+	if c.running > c.max {
+		c.max = c.running
+	}
+
+	c.mu.Unlock()
+
+	time.Sleep(time.Millisecond)
+
+	c.mu.Lock()
+	c.running--
+	c.mu.Unlock()
+
+	return nil
+}
+
+func TestWithWorkerGroup(t *testing.T) {
+	const limit = 3
+	const records = 40
+	const workers = 4
+
+	data := strings.Repeat("some record\n", records)
+
+	group := NewWorkerGroup(limit)
+	counter := &runningCounter{}
+
+	helpers := []struct {
+		name string
+		run  func() error
+	}{
+		{
+			"TransformParallelOrdered",
+			func() error {
+				return TransformParallelOrdered(strings.NewReader(data), io.Discard, workers, func(b []byte) ([]byte, error) {
+					return b, counter.handle(b)
+				}, DelimiteByNewLine, WithWorkerGroup(group))
+			},
+		},
+		{
+			"ProcessDataSourceInChunksConcurrent",
+			func() error {
+				return ProcessDataSourceInChunksConcurrent(
+					strings.NewReader(data), 8, workers, counter.handle, DelimiteByNewLine, WithWorkerGroup(group))
+			},
+		},
+		{
+			"ProcessSharded",
+			func() error {
+				keyFn := func(b []byte) uint64 { return uint64(len(b)) }
+				handlers := []DataChunkHandler{counter.handle, counter.handle, counter.handle, counter.handle}
+
+				return ProcessSharded(
+					strings.NewReader(data), workers, keyFn, handlers, DelimiteByNewLine, WithWorkerGroup(group))
+			},
+		},
+		{
+			"ProcessShardsParallel",
+			func() error {
+				return ProcessShardsParallel(
+					bytes.NewReader([]byte(data)),
+					int64(len(data)),
+					workers,
+					8,
+					counter.handle,
+					DelimiteByNewLine,
+					WithWorkerGroup(group))
+			},
+		},
+	}
+
+	errs := make([]error, len(helpers))
+
+	var wg sync.WaitGroup
+
+	for i, helper := range helpers {
+		wg.Add(1)
+
+		go func(i int, run func() error) {
+			defer wg.Done()
+
+			errs[i] = run()
+		}(i, helper.run)
+	}
+
+	wg.Wait()
+
+	for i, helper := range helpers {
+		if errs[i] != nil {
+			t.Errorf("%s: unexpected error [%v]", helper.name, errs[i])
+		}
+	}
+
+	if counter.max > limit {
+		t.Errorf("got up to [%d] handlers running at once, want at most [%d]", counter.max, limit)
+	}
+
+	if want := len(helpers) * records; counter.handled != want {
+		t.Errorf("got [%d] chunks handled, want [%d]", counter.handled, want)
+	}
+}
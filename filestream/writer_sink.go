@@ -0,0 +1,19 @@
+package filestream
+
+import "io"
+
+// WriteChunksTo, builds a DataChunkHandler that writes every chunk to the writer followed by the separator, such as a
+// new line to turn the chunks back into lines, so filtering or transforming a data source into another one only takes
+// wrapping this handler. An error from the writer stops the processing, just as one from any handler.
+// NOTE: the chunk and the separator are written in a single write, through a buffer reused for every chunk, so writers
+// that are not buffered, such as files, are not written twice per chunk.
+func WriteChunksTo(w io.Writer, separator []byte) DataChunkHandler {
+	var record []byte
+
+	return func(b []byte) error {
+		record = append(append(record[:0], b...), separator...)
+		_, err := w.Write(record)
+
+		return err
+	}
+}
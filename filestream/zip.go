@@ -0,0 +1,125 @@
+package filestream
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/krolaw/zipstream"
+)
+
+// ProcessFirstZipEntry, processes in chunks the content of the first entry of a zip archive read as a stream, using
+// github.com/krolaw/zipstream, and returns the header of that entry.
+// NOTE: only the first entry is processed, any other entry of the archive is ignored, it fits archives known to
+// contain a single file.
+func ProcessFirstZipEntry(
+	dataSource io.Reader,
+	chunkSize int,
+	chunkHandler DataChunkHandler,
+	chunkDelimiter DataChunkDelimiter) (*zip.FileHeader, error) {
+	zipStreamData := zipstream.NewReader(dataSource)
+	entryHeader, err := nextZipEntry(zipStreamData)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = ProcessDataSourceInChunks(zipStreamData, chunkSize, chunkHandler, chunkDelimiter)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return entryHeader, nil
+}
+
+// ProcessZipEntries, processes in chunks the content of every entry of a zip archive read as a stream, one after the
+// other in the order they are stored, handing each chunk to the handler along with the name of the entry it came from.
+// Directory entries have no content and are skipped. An ErrStopProcessing returned by the handler stops the processing
+// of the whole archive, not only of the entry being processed, while any other error is returned along with the name
+// of the entry it was found in. ProcessZipEntriesWithHeader hands over the whole header of the entry instead.
+// NOTE: the same delimiter is used for all the entries, a delimiter holding state about the data, such as the one built
+// by DelimiteByJSONAuto, would carry it from one entry into the next.
+func ProcessZipEntries(
+	dataSource io.Reader,
+	chunkSize int,
+	chunkHandler func(entryName string, chunk []byte) error,
+	chunkDelimiter DataChunkDelimiter) error {
+	entryHandler := func(entryHeader *zip.FileHeader, chunk []byte) error {
+		return chunkHandler(entryHeader.Name, chunk)
+	}
+
+	return ProcessZipEntriesWithHeader(dataSource, chunkSize, entryHandler, chunkDelimiter)
+}
+
+// ProcessZipEntriesWithHeader, same as ProcessZipEntries but each chunk is handed over along with the header of the
+// entry it came from, such as to report the compression method and ratio of every entry. The header is the one found
+// in the local file header of the entry, since the archive is read as a stream, so the CRC-32 and sizes of an entry
+// written with a data descriptor (bit 3 of its flags) are zero whenever the archiver did not know them before writing
+// its data.
+func ProcessZipEntriesWithHeader(
+	dataSource io.Reader,
+	chunkSize int,
+	chunkHandler func(entryHeader *zip.FileHeader, chunk []byte) error,
+	chunkDelimiter DataChunkDelimiter) error {
+	zipStreamData := zipstream.NewReader(dataSource)
+
+	for {
+		entryHeader, err := nextZipEntry(zipStreamData)
+
+		// the central directory, found after the last entry, ends the archive.
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if entryHeader.FileInfo().IsDir() {
+			continue
+		}
+
+		// stopped, whether the handler stopped the processing of the whole archive, not only of the current entry.
+		stopped := false
+
+		entryHandler := func(b []byte) error {
+			err := chunkHandler(entryHeader, b)
+			stopped = errors.Is(err, ErrStopProcessing)
+
+			return err
+		}
+
+		err = ProcessDataSourceInChunks(zipStreamData, chunkSize, entryHandler, chunkDelimiter)
+
+		if err != nil {
+			return fmt.Errorf("zip entry [%s]: %w", entryHeader.Name, err)
+		}
+
+		if stopped {
+			return nil
+		}
+	}
+}
+
+// nextZipEntry, moves the zip stream to its next entry and returns the header of it. zipstream only fills the 32 bits
+// sizes from the local file header, so the 64 bits ones, which archive/zip documents as the ones to be used, are
+// filled from them.
+func nextZipEntry(zipStreamData *zipstream.Reader) (*zip.FileHeader, error) {
+	entryHeader, err := zipStreamData.Next()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if entryHeader.CompressedSize64 == 0 {
+		entryHeader.CompressedSize64 = uint64(entryHeader.CompressedSize)
+	}
+
+	if entryHeader.UncompressedSize64 == 0 {
+		entryHeader.UncompressedSize64 = uint64(entryHeader.UncompressedSize)
+	}
+
+	return entryHeader, nil
+}
@@ -0,0 +1,188 @@
+package filestream
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"errors"
+	"hash/crc32"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// zipArchive, a zip archive holding the given entries, in the order they are given, as name and content pairs.
+func zipArchive(t *testing.T, entries ...string) []byte {
+	t.Helper()
+
+	var archive bytes.Buffer
+	writer := zip.NewWriter(&archive)
+
+	for i := 0; i+1 < len(entries); i += 2 {
+		entry, err := writer.Create(entries[i])
+
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if _, err = entry.Write([]byte(entries[i+1])); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return archive.Bytes()
+}
+
+func TestProcessZipEntries(t *testing.T) {
+	archive := zipArchive(t, "a.txt", "a1\na2\n", "dir/", "", "b.txt", "b1")
+	var got []string
+
+	chunkHandler := func(entryName string, chunk []byte) error {
+		got = append(got, entryName+":"+string(chunk))
+		return nil
+	}
+
+	if err := ProcessZipEntries(bytes.NewReader(archive), 4, chunkHandler, DelimiteByNewLine); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{"a.txt:a1", "a.txt:a2", "b.txt:b1"}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestProcessZipEntriesStop(t *testing.T) {
+	archive := zipArchive(t, "a.txt", "a1\na2\n", "b.txt", "b1\n")
+	var got []string
+
+	chunkHandler := func(entryName string, chunk []byte) error {
+		got = append(got, entryName+":"+string(chunk))
+		return ErrStopProcessing
+	}
+
+	if err := ProcessZipEntries(bytes.NewReader(archive), 4, chunkHandler, DelimiteByNewLine); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{"a.txt:a1"}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestProcessZipEntriesError(t *testing.T) {
+	archive := zipArchive(t, "a.txt", "a1\n", "b.txt", "b1\n")
+	errHandler := errors.New("handler failed")
+
+	chunkHandler := func(entryName string, chunk []byte) error {
+		if entryName == "b.txt" {
+			return errHandler
+		}
+
+		return nil
+	}
+
+	err := ProcessZipEntries(bytes.NewReader(archive), 4, chunkHandler, DelimiteByNewLine)
+
+	if !errors.Is(err, errHandler) {
+		t.Fatalf("got error %v, want %v", err, errHandler)
+	}
+
+	if !strings.Contains(err.Error(), "b.txt") {
+		t.Errorf("the error does not tell the entry: %v", err)
+	}
+}
+
+// rawZipEntry, writes the content as an entry of the archive compressed by the given method, with its CRC-32 and sizes
+// in the local file header, as archivers that know them before writing the entry data do.
+func rawZipEntry(t *testing.T, writer *zip.Writer, name string, method uint16, content string) {
+	t.Helper()
+
+	data := []byte(content)
+
+	if method == zip.Deflate {
+		var compressed bytes.Buffer
+		compressor, _ := flate.NewWriter(&compressed, flate.BestCompression)
+		_, _ = compressor.Write(data)
+		_ = compressor.Close()
+
+		data = compressed.Bytes()
+	}
+
+	entry, err := writer.CreateRaw(&zip.FileHeader{
+		Name:               name,
+		Method:             method,
+		CRC32:              crc32.ChecksumIEEE([]byte(content)),
+		CompressedSize64:   uint64(len(data)),
+		UncompressedSize64: uint64(len(content)),
+	})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err = entry.Write(data); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestProcessZipEntriesWithHeader(t *testing.T) {
+	content := strings.Repeat("record\n", 20)
+
+	var archive bytes.Buffer
+	writer := zip.NewWriter(&archive)
+	rawZipEntry(t, writer, "stored.txt", zip.Store, content)
+	rawZipEntry(t, writer, "deflated.txt", zip.Deflate, content)
+
+	if err := writer.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	type entryMeta struct {
+		method           uint16
+		compressedSize   uint64
+		uncompressedSize uint64
+		chunks           int
+	}
+
+	got := map[string]entryMeta{}
+
+	chunkHandler := func(entryHeader *zip.FileHeader, chunk []byte) error {
+		meta := got[entryHeader.Name]
+		meta.method = entryHeader.Method
+		meta.compressedSize = entryHeader.CompressedSize64
+		meta.uncompressedSize = entryHeader.UncompressedSize64
+		meta.chunks++
+		got[entryHeader.Name] = meta
+
+		return nil
+	}
+
+	err := ProcessZipEntriesWithHeader(bytes.NewReader(archive.Bytes()), 16, chunkHandler, DelimiteByNewLine)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	stored := got["stored.txt"]
+	deflated := got["deflated.txt"]
+
+	if stored.method != zip.Store || stored.compressedSize != uint64(len(content)) || stored.chunks != 20 {
+		t.Errorf("got stored entry %+v", stored)
+	}
+
+	if deflated.method != zip.Deflate || deflated.compressedSize >= uint64(len(content)) || deflated.chunks != 20 {
+		t.Errorf("got deflated entry %+v", deflated)
+	}
+
+	if stored.uncompressedSize != uint64(len(content)) || deflated.uncompressedSize != uint64(len(content)) {
+		t.Errorf("got uncompressed sizes [%d] and [%d]", stored.uncompressedSize, deflated.uncompressedSize)
+	}
+}
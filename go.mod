@@ -2,4 +2,8 @@ module github.com/RafaelPereiraSantos/go-file-stream-reader
 
 go 1.17
 
-require github.com/krolaw/zipstream v0.0.0-20180621105154-0a2661891f94
+require (
+	github.com/klauspost/compress v1.15.15
+	github.com/krolaw/zipstream v0.0.0-20180621105154-0a2661891f94
+	github.com/pierrec/lz4/v4 v4.1.22
+)